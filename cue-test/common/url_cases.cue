@@ -0,0 +1,23 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+cases: {
+	"http URL": {schema: #url, input: "http://localhost:9090", valid: true}
+	"https URL with path": {schema: #url, input: "https://demo.perses.dev/api/v1", valid: true}
+	"missing scheme": {schema: #url, input: "localhost:9090", valid: false}
+	"unsupported scheme": {schema: #url, input: "ftp://localhost", valid: false}
+	"space in host": {schema: #url, input: "http://local host", valid: false}
+	"not a string": {schema: #url, input: 9090, valid: false}
+}
@@ -0,0 +1,97 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/notes"
+	"github.com/sirupsen/logrus"
+)
+
+// rangeStart returns the tag the changelog ending at to starts from, i.e. the previous v* tag.
+// When to is a tag itself, the previous tag is looked up from its parent, otherwise it would be to.
+func rangeStart(to string) (string, error) {
+	if git.RefExists("refs/tags/" + to) {
+		return git.PreviousTag(to + "^")
+	}
+	return git.PreviousTag(to)
+}
+
+// This script prints the changelog of any range of commits, optionally restricted to a single workspace.
+// It doesn't create anything, it's meant to produce release notes ad-hoc.
+//
+// Usage:
+//
+// Changelog since the previous tag:
+//
+//	go run ./scripts/changelog
+//
+// Changelog of the components workspace between two tags, as JSON:
+//
+//	go run ./scripts/changelog --from v0.53.0 --to v0.54.0 --workspace components --format json
+//
+// Only the features and bug fixes since the previous tag:
+//
+//	go run ./scripts/changelog --include-types FEATURE,BUGFIX
+func main() {
+	from := flag.String("from", "", "Start of the range of commits, defaults to the previous v* tag")
+	to := flag.String("to", "HEAD", "End of the range of commits")
+	workspace := flag.String("workspace", "", "Only keep the commits touching the given workspace path")
+	format := flag.String("format", "md", "Output format: md or json")
+	var filter notes.TypeFilter
+	filter.RegisterFlags()
+	flag.Parse()
+
+	if err := filter.Validate(); err != nil {
+		logrus.Fatal(err)
+	}
+
+	if *format != "md" && *format != "json" {
+		logrus.Fatalf("invalid format %q, expected md or json", *format)
+	}
+
+	if *from == "" {
+		previousTag, err := rangeStart(*to)
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to get the previous tag")
+		}
+		if previousTag == "" {
+			logrus.Fatalf("no previous tag found from %s, use --from to set the start of the range", *to)
+		}
+		*from = previousTag
+	}
+
+	var paths []string
+	if *workspace != "" {
+		paths = append(paths, *workspace)
+	}
+	entries, err := git.Log(*from, *to, paths...)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to get the git logs")
+	}
+	logrus.Debugf("%d commit(s) found between %s and %s", len(entries), *from, *to)
+
+	if *format == "json" {
+		data, err := notes.JSON(entries, filter)
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to generate the changelog")
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Print(notes.Markdown(entries, filter))
+}
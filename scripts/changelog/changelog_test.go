@@ -0,0 +1,58 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestRangeStart(t *testing.T) {
+	testutil.GitRepo(t)
+	testutil.Commit(t, "[FEATURE] first feature")
+	testutil.Git(t, "tag", "v0.1.0")
+	testutil.Commit(t, "[FEATURE] second feature")
+	testutil.Commit(t, "[BUGFIX] first fix")
+	testutil.Git(t, "tag", "v0.2.0")
+	testutil.Commit(t, "[ENHANCEMENT] unreleased change")
+
+	tests := []struct {
+		to          string
+		wantFrom    string
+		wantCommits int
+	}{
+		{to: "v0.2.0", wantFrom: "v0.1.0", wantCommits: 2},
+		{to: "HEAD", wantFrom: "v0.2.0", wantCommits: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.to, func(t *testing.T) {
+			from, err := rangeStart(test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != test.wantFrom {
+				t.Fatalf("the changelog up to %s starts from %q, expected %q", test.to, from, test.wantFrom)
+			}
+			entries, err := git.Log(from, test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != test.wantCommits {
+				t.Errorf("got %d commit(s) between %s and %s, expected %d: %v", len(entries), from, test.to, test.wantCommits, entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/notes"
+	"github.com/perses/shared/scripts/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// checkBump returns an error when version is not bumped enough compared to previousTag, given the commits since that tag.
+func checkBump(previousTag string, version string) error {
+	previous, err := semver.Parse(previousTag)
+	if err != nil {
+		return fmt.Errorf("unable to parse the previous tag %s: %w", previousTag, err)
+	}
+	current, err := semver.Parse(version)
+	if err != nil {
+		return fmt.Errorf("unable to parse the version of the root package.json: %w", err)
+	}
+
+	if previous.IsPrerelease() && previous.Major == current.Major && previous.Minor == current.Minor && previous.Patch == current.Patch {
+		logrus.Infof("✓ %s is a prerelease of %s, nothing to check", previousTag, current)
+		return nil
+	}
+
+	entries, err := git.Log(previousTag, "HEAD")
+	if err != nil {
+		return fmt.Errorf("unable to get the git logs: %w", err)
+	}
+	required := notes.RequiredBump(entries, previous)
+	applied := semver.Diff(previous, current)
+	if applied < required {
+		return fmt.Errorf("version %s is a %s bump of %s, but the %d commit(s) since then require a %s bump", current, applied, previousTag, len(entries), required)
+	}
+	logrus.Infof("✓ version %s is a %s bump of %s, %s required", current, applied, previousTag, required)
+	return nil
+}
+
+// This script fails when the version of the root package.json is not bumped enough compared to the previous tag,
+// given the commits since that tag: a [BREAKINGCHANGE] requires a major bump (a minor one before 1.0.0),
+// a [FEATURE] a minor bump and any other change a patch bump.
+// When the previous tag is a prerelease of the current version, the bump has already been checked for the first
+// prerelease and there is nothing to check.
+//
+// Usage:
+//
+//	go run ./scripts/check-bump
+func main() {
+	previousTag, err := git.PreviousTag("HEAD")
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to get the previous tag")
+	}
+	if previousTag == "" {
+		logrus.Info("No previous tag found, nothing to check")
+		return
+	}
+	if err := checkBump(previousTag, npm.MustGetVersion(".")); err != nil {
+		logrus.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestCheckBump(t *testing.T) {
+	tests := []struct {
+		name        string
+		previousTag string
+		subjects    []string
+		version     string
+		wantErr     bool
+	}{
+		{
+			name:        "minor bump for a feature",
+			previousTag: "v1.0.0",
+			subjects:    []string{"[FEATURE] add a markdown panel", "[BUGFIX] fix the panel header"},
+			version:     "1.1.0",
+		},
+		{
+			name:        "major bump for a feature",
+			previousTag: "v1.0.0",
+			subjects:    []string{"[FEATURE] add a markdown panel"},
+			version:     "2.0.0",
+		},
+		{
+			name:        "patch bump for a feature",
+			previousTag: "v1.0.0",
+			subjects:    []string{"[FEATURE] add a markdown panel", "[BUGFIX] fix the panel header"},
+			version:     "1.0.1",
+			wantErr:     true,
+		},
+		{
+			name:        "minor bump for a breaking change",
+			previousTag: "v1.0.0",
+			subjects:    []string{"[BREAKINGCHANGE] remove the legacy layout"},
+			version:     "1.1.0",
+			wantErr:     true,
+		},
+		{
+			name:        "minor bump for a breaking change before 1.0.0",
+			previousTag: "v0.53.0",
+			subjects:    []string{"[BREAKINGCHANGE] remove the legacy layout"},
+			version:     "0.54.0",
+		},
+		{
+			name:        "no bump",
+			previousTag: "v1.0.0",
+			subjects:    []string{"[BUGFIX] fix the panel header"},
+			version:     "1.0.0",
+			wantErr:     true,
+		},
+		{
+			name:        "next prerelease",
+			previousTag: "v1.1.0-rc.0",
+			subjects:    []string{"[FEATURE] add a markdown panel"},
+			version:     "1.1.0-rc.1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			testutil.Commit(t, "[FEATURE] add the core library")
+			testutil.Git(t, "tag", test.previousTag)
+			for _, subject := range test.subjects {
+				testutil.Commit(t, subject)
+			}
+			if err := checkBump(test.previousTag, test.version); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
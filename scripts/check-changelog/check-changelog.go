@@ -0,0 +1,88 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/notes"
+	"github.com/sirupsen/logrus"
+)
+
+// checkChangelog returns an error when the sources of the given workspaces changed since from, but fewer than minEntries of the
+// commits produce a user-facing changelog entry.
+func checkChangelog(from string, workspaces []string, minEntries int) error {
+	var sources []string
+	for _, workspace := range workspaces {
+		sources = append(sources, filepath.Join(workspace, "src"))
+	}
+	sourceCommits, err := git.Log(from, "HEAD", sources...)
+	if err != nil {
+		return fmt.Errorf("unable to get the git logs: %w", err)
+	}
+	if len(sourceCommits) == 0 {
+		logrus.Infof("✓ No change to the libraries sources since %s", from)
+		return nil
+	}
+
+	entries, err := git.Log(from, "HEAD")
+	if err != nil {
+		return fmt.Errorf("unable to get the git logs: %w", err)
+	}
+	userFacing := notes.UserFacing(entries)
+	if len(userFacing) < minEntries {
+		return fmt.Errorf("%d commit(s) changed the libraries sources since %s, but only %d user-facing changelog entry(ies) found (%d required): "+
+			"use the [FEATURE], [ENHANCEMENT], [BUGFIX] or [BREAKINGCHANGE] catalog entry to document the impact of the changes",
+			len(sourceCommits), from, len(userFacing), minEntries)
+	}
+	logrus.Infof("✓ %d user-facing changelog entry(ies) found since %s", len(userFacing), from)
+	return nil
+}
+
+// This script fails when the sources of the libraries changed since the previous tag, but none of the commits
+// would produce a user-facing entry in the changelog ([FEATURE], [ENHANCEMENT], [BUGFIX] or [BREAKINGCHANGE]).
+// It's meant to run in the CI, to prompt the authors to document the impact of their changes.
+//
+// Usage:
+//
+//	go run ./scripts/check-changelog
+//
+// To require several user-facing entries, or to check against another base than the previous tag:
+//
+//	go run ./scripts/check-changelog --min-entries 2 --from origin/main
+func main() {
+	from := flag.String("from", "", "Start of the range of commits, defaults to the previous v* tag")
+	minEntries := flag.Int("min-entries", 1, "Minimum number of user-facing changelog entries required when the sources changed")
+	flag.Parse()
+
+	if *from == "" {
+		previousTag, err := git.PreviousTag("HEAD")
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to get the previous tag")
+		}
+		if previousTag == "" {
+			logrus.Info("No previous tag found, nothing to check")
+			return
+		}
+		*from = previousTag
+	}
+
+	if err := checkChangelog(*from, npm.MustGetWorkspaces("."), *minEntries); err != nil {
+		logrus.Fatal(err)
+	}
+}
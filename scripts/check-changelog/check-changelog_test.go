@@ -0,0 +1,68 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestCheckChangelog(t *testing.T) {
+	type commit struct {
+		subject string
+		file    string
+	}
+	tests := []struct {
+		name    string
+		commits []commit
+		wantErr bool
+	}{
+		{
+			name: "only chores changing the sources",
+			commits: []commit{
+				{subject: "[IGNORE] refactor the panel header", file: "core/src/panel.ts"},
+				{subject: "chore: bump dependencies", file: "package.json"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fix changing the sources",
+			commits: []commit{
+				{subject: "[IGNORE] refactor the panel header", file: "core/src/panel.ts"},
+				{subject: "[BUGFIX] fix the panel header", file: "core/src/header.ts"},
+			},
+		},
+		{
+			name: "only chores outside the sources",
+			commits: []commit{
+				{subject: "chore: bump dependencies", file: "package.json"},
+				{subject: "[DOC] document the panels", file: "core/README.md"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			testutil.Commit(t, "[FEATURE] add the core library", "core/src/index.ts")
+			testutil.Git(t, "tag", "v0.1.0")
+			for _, c := range test.commits {
+				testutil.Commit(t, c.subject, c.file)
+			}
+			if err := checkChangelog("v0.1.0", []string{"core", "components"}, 1); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
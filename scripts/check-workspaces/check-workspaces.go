@@ -0,0 +1,43 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/workspace"
+	"github.com/sirupsen/logrus"
+)
+
+// This script fails when a directory is matched by several workspace patterns of the root package.json
+// (or when the same workspace is listed twice), as the package would then be built and published twice.
+//
+// Usage:
+//
+//	go run ./scripts/check-workspaces
+func main() {
+	patterns := npm.MustGetWorkspaces(".")
+	overlaps, err := workspace.Overlaps(patterns)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to expand the workspaces")
+	}
+	for _, overlap := range overlaps {
+		logrus.Errorf("workspace %s is matched by several patterns: %s", overlap.Dir, strings.Join(overlap.Patterns, ", "))
+	}
+	if len(overlaps) > 0 {
+		logrus.Fatalf("%d workspace(s) matched more than once", len(overlaps))
+	}
+	logrus.Infof("✓ %d workspace pattern(s) without overlap", len(patterns))
+}
@@ -0,0 +1,50 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+var strict bool
+
+// ParallelFlag registers the --parallel flag, the concurrency limit shared by the scripts processing workspaces concurrently.
+func ParallelFlag() *int {
+	return flag.Int("parallel", 4, "Maximum number of workspaces processed concurrently")
+}
+
+// RegisterFlags registers the flags shared by all the release scripts.
+func RegisterFlags() {
+	flag.BoolVar(&strict, "strict", false, "Treat validation warnings as errors")
+}
+
+// Strict returns true when validation warnings must be treated as errors.
+func Strict() bool {
+	return strict
+}
+
+// Warnf reports a warn-level validation issue.
+// It is only logged by default, but returned as an error when --strict is set so that the caller fails.
+func Warnf(format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if strict {
+		return errors.New(msg)
+	}
+	logrus.Warn(msg)
+	return nil
+}
@@ -0,0 +1,56 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"strconv"
+	"testing"
+)
+
+func TestWarnf(t *testing.T) {
+	RegisterFlags()
+	tests := []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{
+			name: "warning only logged by default",
+		},
+		{
+			name:    "warning fails under --strict",
+			strict:  true,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := flag.Set("strict", strconv.FormatBool(test.strict)); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { strict = false })
+			if Strict() != test.strict {
+				t.Fatalf("got Strict() %t, expected %t", Strict(), test.strict)
+			}
+			err := Warnf("%d commit(s) without catalog entry", 2)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if err != nil && err.Error() != "2 commit(s) without catalog entry" {
+				t.Errorf("unexpected error message %q", err)
+			}
+		})
+	}
+}
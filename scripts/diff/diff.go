@@ -0,0 +1,148 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines displayed around each change.
+const contextLines = 3
+
+type edit struct {
+	kind byte // ' ' for an unchanged line, '-' for a removed one, '+' for an added one
+	line string
+}
+
+// noNewline is appended to the last line when the content doesn't end with a newline, the way diff reports it.
+// Being part of the line, it also makes a line differ from the same one with a final newline.
+const noNewline = "\n\\ No newline at end of file"
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	content, hasNewline := strings.CutSuffix(string(data), "\n")
+	lines := strings.Split(content, "\n")
+	if !hasNewline {
+		lines[len(lines)-1] += noNewline
+	}
+	return lines
+}
+
+// edits computes the shortest edit script between a and b, based on their longest common subsequence.
+func edits(a []string, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, edit{kind: ' ', line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, edit{kind: '-', line: a[i]})
+			i++
+		default:
+			result = append(result, edit{kind: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, edit{kind: '-', line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, edit{kind: '+', line: b[j]})
+	}
+	return result
+}
+
+// hunkRange returns the 1-based start and the length of a hunk, in the format expected by the hunk header.
+func hunkRange(start int, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+func writeHunk(builder *strings.Builder, script []edit, start int, end int) {
+	originalStart, proposedStart := 0, 0
+	for _, e := range script[:start] {
+		if e.kind != '+' {
+			originalStart++
+		}
+		if e.kind != '-' {
+			proposedStart++
+		}
+	}
+	originalLength, proposedLength := 0, 0
+	for _, e := range script[start:end] {
+		if e.kind != '+' {
+			originalLength++
+		}
+		if e.kind != '-' {
+			proposedLength++
+		}
+	}
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", hunkRange(originalStart, originalLength), hunkRange(proposedStart, proposedLength))
+	for _, e := range script[start:end] {
+		builder.WriteByte(e.kind)
+		builder.WriteString(e.line)
+		builder.WriteByte('\n')
+	}
+}
+
+// Unified renders the unified diff between the original and the proposed content of the file name.
+// It returns an empty string when both contents are identical.
+func Unified(name string, original []byte, proposed []byte) string {
+	script := edits(splitLines(original), splitLines(proposed))
+	var changes []int
+	for i, e := range script {
+		if e.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- a/%s\n+++ b/%s\n", name, name)
+	start := max(0, changes[0]-contextLines)
+	end := min(len(script), changes[0]+1+contextLines)
+	for _, change := range changes[1:] {
+		if change-contextLines > end {
+			writeHunk(&builder, script, start, end)
+			start = change - contextLines
+		}
+		end = min(len(script), change+1+contextLines)
+	}
+	writeHunk(&builder, script, start, end)
+	return builder.String()
+}
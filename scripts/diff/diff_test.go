@@ -0,0 +1,136 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff
+
+import (
+	"testing"
+)
+
+const packageJSON = `{
+  "name": "@perses-dev/components",
+  "version": "0.53.0",
+  "description": "Common components used across Perses features",
+  "license": "Apache-2.0",
+  "homepage": "https://github.com/perses/shared/blob/main/README.md",
+  "repository": {
+    "type": "git",
+    "url": "git+https://github.com/perses/shared.git"
+  },
+  "dependencies": {
+    "@perses-dev/core": "0.53.0",
+    "lodash": "^4.17.21"
+  }
+}
+`
+
+const bumpedPackageJSON = `{
+  "name": "@perses-dev/components",
+  "version": "0.54.0",
+  "description": "Common components used across Perses features",
+  "license": "Apache-2.0",
+  "homepage": "https://github.com/perses/shared/blob/main/README.md",
+  "repository": {
+    "type": "git",
+    "url": "git+https://github.com/perses/shared.git"
+  },
+  "dependencies": {
+    "@perses-dev/core": "0.54.0",
+    "lodash": "^4.17.21"
+  }
+}
+`
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		proposed string
+		want     string
+	}{
+		{
+			name:     "identical",
+			original: packageJSON,
+			proposed: packageJSON,
+		},
+		{
+			name:     "version bump",
+			original: packageJSON,
+			proposed: bumpedPackageJSON,
+			want: `--- a/package.json
++++ b/package.json
+@@ -1,6 +1,6 @@
+ {
+   "name": "@perses-dev/components",
+-  "version": "0.53.0",
++  "version": "0.54.0",
+   "description": "Common components used across Perses features",
+   "license": "Apache-2.0",
+   "homepage": "https://github.com/perses/shared/blob/main/README.md",
+@@ -9,7 +9,7 @@
+     "url": "git+https://github.com/perses/shared.git"
+   },
+   "dependencies": {
+-    "@perses-dev/core": "0.53.0",
++    "@perses-dev/core": "0.54.0",
+     "lodash": "^4.17.21"
+   }
+ }
+`,
+		},
+		{
+			name:     "missing final newline",
+			original: "{\n  \"name\": \"core\"\n}",
+			proposed: "{\n  \"name\": \"core\"\n}\n",
+			want: `--- a/package.json
++++ b/package.json
+@@ -1,3 +1,3 @@
+ {
+   "name": "core"
+-}
+\ No newline at end of file
++}
+`,
+		},
+		{
+			name:     "change without final newline",
+			original: "{\n  \"version\": \"0.1.0\"\n}",
+			proposed: "{\n  \"version\": \"0.2.0\"\n}",
+			want: `--- a/package.json
++++ b/package.json
+@@ -1,3 +1,3 @@
+ {
+-  "version": "0.1.0"
++  "version": "0.2.0"
+ }
+\ No newline at end of file
+`,
+		},
+		{
+			name:     "new file",
+			proposed: "{}\n",
+			want: `--- a/package.json
++++ b/package.json
+@@ -0,0 +1,1 @@
++{}
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Unified("package.json", []byte(test.original), []byte(test.proposed)); got != test.want {
+				t.Errorf("got:\n%s\nexpected:\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/perses/shared/scripts/semver"
+)
+
+// MinVersion is the oldest gh version supporting all the subcommands and flags used by the scripts
+// (e.g. `gh release list --json`).
+const MinVersion = "2.42.0"
+
+var versionPattern = regexp.MustCompile(`gh version (\d+\.\d+\.\d+\S*)`)
+
+// CheckVersion verifies the installed gh is at least MinVersion.
+func CheckVersion() error {
+	data, err := exec.Command("gh", "--version").Output()
+	if err != nil {
+		return fmt.Errorf("unable to run gh, install it from https://github.com/cli/cli#installation: %w", err)
+	}
+	matches := versionPattern.FindStringSubmatch(string(data))
+	if matches == nil {
+		return fmt.Errorf("unable to parse the gh version from %q", strings.TrimSpace(string(data)))
+	}
+	installed, err := semver.Parse(matches[1])
+	if err != nil {
+		return err
+	}
+	minimum, err := semver.Parse(MinVersion)
+	if err != nil {
+		return err
+	}
+	if semver.Compare(installed, minimum) < 0 {
+		return fmt.Errorf("gh %s is too old, version %s or later is required: upgrade it following https://github.com/cli/cli#installation", installed, MinVersion)
+	}
+	return nil
+}
+
+// ListReleases returns the tag names of every GitHub release of the current repository.
+func ListReleases() ([]string, error) {
+	data, err := exec.Command("gh", "release", "list", "--limit", "1000", "--json", "tagName", "--jq", ".[].tagName").Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the GitHub releases: %w", err)
+	}
+	var releases []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			releases = append(releases, line)
+		}
+	}
+	return releases, nil
+}
+
+// MissingReleases returns the given tags that don't have a corresponding GitHub release, keeping their order.
+func MissingReleases(tags []string) ([]string, error) {
+	releases, err := ListReleases()
+	if err != nil {
+		return nil, err
+	}
+	released := make(map[string]bool, len(releases))
+	for _, release := range releases {
+		released[release] = true
+	}
+	var missing []string
+	for _, tag := range tags {
+		if !released[tag] {
+			missing = append(missing, tag)
+		}
+	}
+	return missing, nil
+}
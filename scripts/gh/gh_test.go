@@ -0,0 +1,66 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		wantErr string
+	}{
+		{
+			name:   "minimum version",
+			output: "gh version 2.42.0 (2024-01-15)",
+		},
+		{
+			name:   "newer version",
+			output: "gh version 2.63.2 (2024-12-05)",
+		},
+		{
+			name:    "too old version",
+			output:  "gh version 2.40.1 (2023-12-13)",
+			wantErr: "gh 2.40.1 is too old, version 2.42.0 or later is required",
+		},
+		{
+			name:    "unexpected output",
+			output:  "gh development build",
+			wantErr: "unable to parse the gh version",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.FakeCommand(t, "gh", `[ "$1" = "--version" ] || exit 2
+echo "`+test.output+`"
+echo "https://github.com/cli/cli/releases/latest"
+`)
+			err := CheckVersion()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
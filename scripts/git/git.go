@@ -0,0 +1,228 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// previousTags caches the result of PreviousTag by reference for the duration of the run, as it's queried by several steps.
+// It's reset by FetchTags, as fetching can bring new tags.
+var previousTags = struct {
+	sync.Mutex
+	tags map[string]string
+}{tags: make(map[string]string)}
+
+// output runs git with the given arguments and returns its standard output.
+func output(args ...string) ([]byte, error) {
+	return exec.Command("git", args...).Output()
+}
+
+// splitLines splits the output of a git command into non-empty lines.
+func splitLines(data []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// ListTags returns the local tags matching the given pattern (e.g. "v*").
+func ListTags(pattern string) ([]string, error) {
+	data, err := output("tag", "--list", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the local tags: %w", err)
+	}
+	return splitLines(data), nil
+}
+
+// FetchTags fetches the tags from the remote.
+func FetchTags() error {
+	if _, err := output("fetch", "--tags"); err != nil {
+		return fmt.Errorf("unable to fetch the tags: %w", err)
+	}
+	previousTags.Lock()
+	defer previousTags.Unlock()
+	clear(previousTags.tags)
+	return nil
+}
+
+// RefExists returns true if the given reference (e.g. a tag) can be resolved.
+func RefExists(ref string) bool {
+	_, err := output("rev-parse", "--verify", "--quiet", ref)
+	return err == nil
+}
+
+// PreviousTag returns the most recent v* tag reachable from the given reference, or an empty string if there is none.
+// The result is cached, so that the several steps of a run looking it up only spawn git once.
+func PreviousTag(from string) (string, error) {
+	previousTags.Lock()
+	defer previousTags.Unlock()
+	if tag, ok := previousTags.tags[from]; ok {
+		return tag, nil
+	}
+	data, err := output("describe", "--tags", "--abbrev=0", "--match", "v*", from)
+	if err != nil {
+		var exitError *exec.ExitError
+		if !errors.As(err, &exitError) || exitError.ExitCode() != 128 {
+			return "", fmt.Errorf("unable to get the previous tag from %s: %w", from, err)
+		}
+	}
+	tag := strings.TrimSpace(string(data))
+	previousTags.tags[from] = tag
+	return tag, nil
+}
+
+// Log returns the commits between the two given references, in the `<commit> <subject>` format.
+// When paths are given, only the commits touching them are returned.
+// When from is empty, all the commits reachable from to are returned.
+func Log(from string, to string, paths ...string) ([]string, error) {
+	revisions := to
+	if from != "" {
+		revisions = fmt.Sprintf("%s...%s", from, to)
+	}
+	args := []string{"log", revisions, "--pretty=oneline", "--no-decorate"}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	data, err := output(args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the git logs between %s and %s: %w", from, to, err)
+	}
+	return splitLines(data), nil
+}
+
+// DeleteTag deletes the given tag from the local repository.
+func DeleteTag(tag string) error {
+	if err := exec.Command("git", "tag", "--delete", tag).Run(); err != nil {
+		return fmt.Errorf("unable to delete the tag %s: %w", tag, err)
+	}
+	return nil
+}
+
+var (
+	// ErrTagNotSigned is returned by VerifyTag when the tag doesn't carry any signature.
+	ErrTagNotSigned = errors.New("tag is not signed")
+	// ErrTagBadSignature is returned by VerifyTag when the tag signature cannot be verified.
+	ErrTagBadSignature = errors.New("tag signature cannot be verified")
+)
+
+// VerifyTag checks the GPG signature of the given tag with `git tag -v`.
+// Lightweight tags and annotated tags without signature are reported with ErrTagNotSigned,
+// any other verification failure is reported with ErrTagBadSignature.
+func VerifyTag(tag string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "tag", "--verify", tag)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stderr.String())
+		if strings.Contains(output, "no signature found") || strings.Contains(output, "cannot verify a non-tag object") {
+			return fmt.Errorf("%w: %s", ErrTagNotSigned, tag)
+		}
+		return fmt.Errorf("%w: %s\n%s", ErrTagBadSignature, tag, output)
+	}
+	return nil
+}
+
+// TagTrailer returns the value of the given trailer (e.g. `Version: 1.2.3`) in the annotation of the tag.
+// It returns an empty string when the tag is lightweight, or when its annotation doesn't have the trailer.
+func TagTrailer(tag string, key string) (string, error) {
+	objectType, err := output("cat-file", "-t", tag)
+	if err != nil {
+		return "", fmt.Errorf("unable to get the type of tag %s: %w", tag, err)
+	}
+	if strings.TrimSpace(string(objectType)) != "tag" {
+		return "", nil
+	}
+	format := fmt.Sprintf("%%(contents:trailers:key=%s,valueonly)", key)
+	data, err := output("tag", "--list", "--format", format, tag)
+	if err != nil {
+		return "", fmt.Errorf("unable to get the annotation of tag %s: %w", tag, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ShowFile returns the content of the file at the given path, as of the given reference.
+func ShowFile(ref string, path string) ([]byte, error) {
+	data, err := output("show", fmt.Sprintf("%s:%s", ref, path))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s at %s: %w", path, ref, err)
+	}
+	return data, nil
+}
+
+// ExtractTree extracts the files under the given path, as of the given reference, into dir.
+// The files keep their path relative to the repository root.
+func ExtractTree(ref string, path string, dir string) error {
+	data, err := output("archive", "--format=tar", ref, path)
+	if err != nil {
+		return fmt.Errorf("unable to archive %s at %s: %w", path, ref, err)
+	}
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read the archive of %s at %s: %w", path, ref, err)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dir)+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path %s in the archive of %s at %s", header.Name, path, ref)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil { //nolint: gosec
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil { //nolint: gosec
+				return err
+			}
+			content, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, content, 0644); err != nil { //nolint: gosec
+				return err
+			}
+		}
+	}
+}
+
+// ChangedFiles returns the tracked files changed since the given reference, uncommitted changes included.
+// When paths are given, only the changes under them are returned.
+func ChangedFiles(base string, paths ...string) ([]string, error) {
+	args := []string{"diff", "--name-only", base}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	data, err := output(args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the files changed since %s: %w", base, err)
+	}
+	return splitLines(data), nil
+}
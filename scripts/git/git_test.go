@@ -0,0 +1,136 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package git
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+// recordGit wraps git so that every invocation is appended to the returned log file.
+func recordGit(t *testing.T) string {
+	t.Helper()
+	realGit, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+	log := filepath.Join(t.TempDir(), "git.log")
+	testutil.FakeCommand(t, "git", `echo "$*" >> `+log+`
+exec `+realGit+` "$@"
+`)
+	return log
+}
+
+// countCalls returns the number of recorded git invocations starting with the given command.
+func countCalls(t *testing.T, log string, command string) int {
+	t.Helper()
+	count := 0
+	for _, line := range strings.Split(testutil.ReadFile(t, log), "\n") {
+		if strings.HasPrefix(line, command+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestPreviousTagCache(t *testing.T) {
+	repo := testutil.GitRepo(t)
+	testutil.Commit(t, "[FEATURE] first feature")
+	testutil.Git(t, "tag", "v0.1.0")
+	testutil.Commit(t, "[BUGFIX] first fix")
+	remote := t.TempDir()
+	testutil.Git(t, "clone", "--quiet", repo, remote)
+	testutil.Git(t, "-C", remote, "tag", "v0.2.0")
+	testutil.Git(t, "remote", "add", "origin", remote)
+	clear(previousTags.tags)
+	log := recordGit(t)
+
+	steps := []struct {
+		name         string
+		fetch        bool
+		want         string
+		wantDescribe int
+	}{
+		{name: "first lookup", want: "v0.1.0", wantDescribe: 1},
+		{name: "cached lookup", want: "v0.1.0", wantDescribe: 1},
+		{name: "lookup after fetching a new tag", fetch: true, want: "v0.2.0", wantDescribe: 2},
+		{name: "cached lookup after fetching", want: "v0.2.0", wantDescribe: 2},
+	}
+	for _, step := range steps {
+		if step.fetch {
+			if err := FetchTags(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tag, err := PreviousTag("HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag != step.want {
+			t.Errorf("%s: got %q, expected %q", step.name, tag, step.want)
+		}
+		if got := countCalls(t, log, "describe"); got != step.wantDescribe {
+			t.Errorf("%s: git describe ran %d time(s), expected %d", step.name, got, step.wantDescribe)
+		}
+	}
+}
+
+func TestVerifyTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr error
+	}{
+		{
+			name:   "verified",
+			script: "echo 'gpg: Good signature from \"Perses <perses@example.com>\"' >&2",
+		},
+		{
+			name:    "annotated tag without signature",
+			script:  "echo 'error: no signature found' >&2; exit 1",
+			wantErr: ErrTagNotSigned,
+		},
+		{
+			name:    "lightweight tag",
+			script:  "echo 'error: v0.1.0: cannot verify a non-tag object of type commit.' >&2; exit 1",
+			wantErr: ErrTagNotSigned,
+		},
+		{
+			name:    "bad signature",
+			script:  "echo 'gpg: BAD signature from \"Perses <perses@example.com>\"' >&2; exit 1",
+			wantErr: ErrTagBadSignature,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.FakeCommand(t, "git", `[ "$1 $2 $3" = "tag --verify v0.1.0" ] || exit 2
+`+test.script+"\n")
+			err := VerifyTag("v0.1.0")
+			if test.wantErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, expected %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// path is the lock file shared by all the release scripts, relative to the root of the repository.
+const path = ".release.lock"
+
+const (
+	guardTimeout = 10 * time.Second
+	guardDelay   = 10 * time.Millisecond
+)
+
+type owner struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Flag registers the --lock-timeout flag.
+func Flag() *time.Duration {
+	return flag.Duration("lock-timeout", time.Hour, "Duration after which a lock left by another release run is considered stale")
+}
+
+// Acquire creates the release lock file, failing if another run already holds it.
+// A lock older than timeout is considered stale (e.g. left by a killed run) and is reclaimed.
+func Acquire(timeout time.Duration) error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(owner{PID: os.Getpid(), Host: host, CreatedAt: time.Now()})
+	if err != nil {
+		return err
+	}
+	for {
+		err := create(data)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("unable to create the lock file %s: %w", path, err)
+		}
+		if reclaimErr := reclaim(timeout); reclaimErr != nil {
+			return reclaimErr
+		}
+	}
+}
+
+// create writes the lock file atomically: the content is written to a temporary file which is then linked to path,
+// the link failing if path already exists. This way, a concurrent run never reads a partially written lock.
+func create(data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Link(f.Name(), path)
+}
+
+// reclaim moves the current lock file aside if it is stale, so that the next exclusive create can succeed.
+// Concurrent reclaims are serialized through the guard file: without it, a run that read the stale lock
+// could move aside the fresh lock another run has just created in its place.
+func reclaim(timeout time.Duration) error {
+	guard, err := acquireGuard()
+	if err != nil {
+		return err
+	}
+	defer releaseGuard(guard)
+
+	current, err := readOwner()
+	if errors.Is(err, os.ErrNotExist) {
+		// released in the meantime
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	age := time.Since(current.CreatedAt)
+	if age < timeout {
+		return fmt.Errorf("another release run (pid %d on %s) holds the lock %s since %s, wait for it to complete or remove the file if it's not running anymore",
+			current.PID, current.Host, path, current.CreatedAt.Format(time.RFC3339))
+	}
+	logrus.Warnf("reclaiming the stale lock %s left by pid %d on %s %s ago", path, current.PID, current.Host, age.Round(time.Second))
+	aside := fmt.Sprintf("%s.stale-%d", path, os.Getpid())
+	if renameErr := os.Rename(path, aside); renameErr != nil {
+		if errors.Is(renameErr, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("unable to move aside the stale lock %s: %w", path, renameErr)
+	}
+	if removeErr := os.Remove(aside); removeErr != nil {
+		logrus.WithError(removeErr).Warnf("unable to remove the stale lock %s", aside)
+	}
+	return nil
+}
+
+// acquireGuard creates the guard file protecting a reclaim, waiting for a concurrent reclaim to complete.
+// A guard older than guardTimeout was left by a run killed while reclaiming and is removed.
+func acquireGuard() (string, error) {
+	guard := path + ".reclaim"
+	for {
+		f, err := os.OpenFile(guard, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			return guard, f.Close()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("unable to create the lock guard %s: %w", guard, err)
+		}
+		if info, statErr := os.Stat(guard); statErr == nil && time.Since(info.ModTime()) > guardTimeout {
+			logrus.Warnf("removing the lock guard %s left by an interrupted run", guard)
+			_ = os.Remove(guard)
+			continue
+		}
+		time.Sleep(guardDelay)
+	}
+}
+
+func releaseGuard(guard string) {
+	if err := os.Remove(guard); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.WithError(err).Warnf("unable to remove the lock guard %s", guard)
+	}
+}
+
+func readOwner() (owner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return owner{}, fmt.Errorf("unable to read the lock file %s: %w", path, err)
+	}
+	o := owner{}
+	if unmarshalErr := json.Unmarshal(data, &o); unmarshalErr != nil {
+		return owner{}, fmt.Errorf("unable to parse the lock file %s: %w", path, unmarshalErr)
+	}
+	return o, nil
+}
+
+// Release removes the lock file.
+func Release() {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logrus.WithError(err).Warnf("unable to remove the lock file %s", path)
+	}
+}
+
+// MustAcquire acquires the lock and makes sure it is released when the program exits, including through logrus.Fatal.
+// The caller is responsible for calling Release when returning normally.
+func MustAcquire(timeout time.Duration) {
+	if err := Acquire(timeout); err != nil {
+		logrus.Fatal(err)
+	}
+	logrus.RegisterExitHandler(Release)
+}
@@ -0,0 +1,116 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeLock(t *testing.T, createdAt time.Time) {
+	t.Helper()
+	data, err := json.Marshal(owner{PID: 1, Host: "other", CreatedAt: createdAt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *time.Time
+		wantErr  bool
+	}{
+		{
+			name: "no lock",
+		},
+		{
+			name:     "held lock",
+			existing: new(time.Now().Add(-time.Minute)),
+			wantErr:  true,
+		},
+		{
+			name:     "stale lock",
+			existing: new(time.Now().Add(-2 * time.Hour)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if test.existing != nil {
+				writeLock(t, *test.existing)
+			}
+			err := Acquire(time.Hour)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected the second run to be refused")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			current, err := readOwner()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if current.PID != os.Getpid() {
+				t.Errorf("lock owned by pid %d, expected %d", current.PID, os.Getpid())
+			}
+			Release()
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("lock file still present after release: %v", err)
+			}
+		})
+	}
+}
+
+func TestAcquireConcurrentReclaim(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeLock(t, time.Now().Add(-2*time.Hour))
+
+	const runs = 8
+	var wg sync.WaitGroup
+	errs := make([]error, runs)
+	for i := range runs {
+		wg.Go(func() {
+			errs[i] = Acquire(time.Hour)
+		})
+	}
+	wg.Wait()
+
+	acquired := 0
+	for _, err := range errs {
+		if err == nil {
+			acquired++
+		}
+	}
+	if acquired != 1 {
+		t.Fatalf("%d runs acquired the lock, expected exactly one: %v", acquired, errs)
+	}
+	leftovers, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leftovers) > 0 {
+		t.Errorf("temporary lock files left behind: %v", leftovers)
+	}
+}
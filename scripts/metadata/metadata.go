@@ -0,0 +1,66 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/workspace"
+	"github.com/sirupsen/logrus"
+)
+
+// metadata is the resolved model of the monorepo. It's meant to be consumed by other tooling,
+// so the fields must only be added, never renamed or removed.
+type metadata struct {
+	Version          string                `json:"version"`
+	Workspaces       []workspace.Workspace `json:"workspaces"`
+	TopologicalOrder []string              `json:"topologicalOrder"`
+}
+
+// loadMetadata resolves the model of the monorepo of the current directory.
+func loadMetadata() (metadata, error) {
+	workspaces, err := workspace.Load(npm.MustGetWorkspaces("."))
+	if err != nil {
+		return metadata{}, fmt.Errorf("unable to load the workspaces: %w", err)
+	}
+	order, err := workspace.TopologicalOrder(workspaces)
+	if err != nil {
+		return metadata{}, err
+	}
+	return metadata{
+		Version:          npm.MustGetVersion("."),
+		Workspaces:       workspaces,
+		TopologicalOrder: order,
+	}, nil
+}
+
+// This script prints the resolved model of the monorepo as JSON: the workspaces with their dependencies,
+// the dependencies between the workspaces, and the order in which they must be built.
+//
+// Usage:
+//
+//	go run ./scripts/metadata
+func main() {
+	model, err := loadMetadata()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	data, err := json.MarshalIndent(model, "", "  ")
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to marshal the metadata")
+	}
+	fmt.Println(string(data))
+}
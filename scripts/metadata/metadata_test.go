@@ -0,0 +1,73 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestLoadMetadata(t *testing.T) {
+	t.Chdir(t.TempDir())
+	testutil.WriteFiles(t, ".", map[string]string{
+		"package.json":            `{"name": "perses-shared", "version": "0.53.0", "private": true, "workspaces": ["plugins/*", "components", "core"]}`,
+		"core/package.json":       `{"name": "@perses-dev/core", "version": "0.53.0", "dependencies": {"lodash": "^4.17.21"}}`,
+		"components/package.json": `{"name": "@perses-dev/components", "version": "0.53.0", "dependencies": {"@perses-dev/core": "0.53.0"}, "peerDependencies": {"react": "^18.0.0"}}`,
+		"plugins/timeseries/package.json": `{"name": "@perses-dev/timeseries", "version": "0.53.0",
+			"peerDependencies": {"@perses-dev/components": "0.53.0"}, "devDependencies": {"@perses-dev/core": "0.53.0", "@perses-dev/timeseries": "0.53.0"}}`,
+	})
+
+	model, err := loadMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.Version != "0.53.0" {
+		t.Errorf("got version %s, expected 0.53.0", model.Version)
+	}
+	if want := []string{"@perses-dev/core", "@perses-dev/components", "@perses-dev/timeseries"}; !slices.Equal(model.TopologicalOrder, want) {
+		t.Errorf("got topological order %q, expected %q", model.TopologicalOrder, want)
+	}
+	wantEdges := map[string][]string{
+		"@perses-dev/core":       {},
+		"@perses-dev/components": {"@perses-dev/core"},
+		// external, self and dev dependencies aren't edges of the graph
+		"@perses-dev/timeseries": {"@perses-dev/components"},
+	}
+	wantDevEdges := map[string][]string{
+		"@perses-dev/core":       {},
+		"@perses-dev/components": {},
+		"@perses-dev/timeseries": {"@perses-dev/core"},
+	}
+	for _, w := range model.Workspaces {
+		if !slices.Equal(w.InternalDependencies, wantEdges[w.Name]) {
+			t.Errorf("got internal dependencies %q for %s, expected %q", w.InternalDependencies, w.Name, wantEdges[w.Name])
+		}
+		if !slices.Equal(w.InternalDevDependencies, wantDevEdges[w.Name]) {
+			t.Errorf("got internal dev dependencies %q for %s, expected %q", w.InternalDevDependencies, w.Name, wantDevEdges[w.Name])
+		}
+	}
+
+	data, err := json.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// consumers iterate over the edges, a workspace without internal dependency must have an empty list rather than null
+	if !strings.Contains(string(data), `"dir":"core","name":"@perses-dev/core","version":"0.53.0","private":false,"dependencies":{"lodash":"^4.17.21"},"internalDependencies":[],"internalDevDependencies":[]`) {
+		t.Errorf("unexpected JSON model:\n%s", data)
+	}
+}
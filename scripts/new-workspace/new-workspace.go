@@ -0,0 +1,164 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/pkgjson"
+	"github.com/sirupsen/logrus"
+)
+
+const scope = "@perses-dev/"
+
+// remotePattern extracts the owner and the name of the repository from an SSH or HTTPS GitHub remote URL.
+var remotePattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?$`)
+
+// getRepositoryURL returns the HTTPS URL of the GitHub repository the origin remote points to.
+func getRepositoryURL() (string, error) {
+	data, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get the origin remote: %w", err)
+	}
+	remote := strings.TrimSpace(string(data))
+	matches := remotePattern.FindStringSubmatch(remote)
+	if matches == nil {
+		return "", fmt.Errorf("origin remote %s is not a GitHub repository", remote)
+	}
+	return fmt.Sprintf("https://github.com/%s/%s", matches[1], matches[2]), nil
+}
+
+type repository struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type bugs struct {
+	URL string `json:"url"`
+}
+
+// workspacePackage is the package.json scaffolded for a new workspace, with the fields in the canonical order.
+type workspacePackage struct {
+	Name        string     `json:"name"`
+	Version     string     `json:"version"`
+	Description string     `json:"description"`
+	License     string     `json:"license"`
+	Homepage    string     `json:"homepage"`
+	Repository  repository `json:"repository"`
+	Bugs        bugs       `json:"bugs"`
+	Module      string     `json:"module"`
+	Main        string     `json:"main"`
+	Types       string     `json:"types"`
+	Files       []string   `json:"files"`
+}
+
+// newWorkspacePackage returns the package.json of a new workspace, with the metadata shared by all the libraries.
+func newWorkspacePackage(name string, version string, repositoryURL string) workspacePackage {
+	return workspacePackage{
+		Name:     name,
+		Version:  version,
+		License:  "Apache-2.0",
+		Homepage: fmt.Sprintf("%s/blob/main/README.md", repositoryURL),
+		Repository: repository{
+			Type: "git",
+			URL:  fmt.Sprintf("git+%s.git", repositoryURL),
+		},
+		Bugs:   bugs{URL: fmt.Sprintf("%s/issues", repositoryURL)},
+		Module: "dist/index.js",
+		Main:   "dist/cjs/index.js",
+		Types:  "dist/index.d.ts",
+		Files:  []string{"dist"},
+	}
+}
+
+func scaffold(workspacePath string, pkg workspacePackage) error {
+	if _, err := os.Stat(filepath.Join(workspacePath, "package.json")); err == nil {
+		return fmt.Errorf("%s already contains a package.json", workspacePath)
+	}
+	if err := os.MkdirAll(workspacePath, 0755); err != nil { // nolint: gosec
+		return err
+	}
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(workspacePath, "package.json"), append(data, '\n'), 0644) // nolint: gosec
+}
+
+// addWorkspace appends the workspace to the root package.json, leaving the rest of the file untouched.
+func addWorkspace(workspacePath string) error {
+	root, err := pkgjson.Read(".")
+	if err != nil {
+		return err
+	}
+	var workspaces []string
+	if raw, ok := root.Get("workspaces"); ok {
+		if unmarshalErr := json.Unmarshal(raw, &workspaces); unmarshalErr != nil {
+			return fmt.Errorf("unable to parse the root workspaces: %w", unmarshalErr)
+		}
+	}
+	if slices.Contains(workspaces, workspacePath) {
+		return fmt.Errorf("%s is already a workspace", workspacePath)
+	}
+	raw, err := json.Marshal(append(workspaces, workspacePath))
+	if err != nil {
+		return err
+	}
+	root.Set("workspaces", raw)
+	return pkgjson.Write(".", root)
+}
+
+// This script scaffolds a new workspace (library) in the monorepo: it creates its package.json with the
+// metadata shared by all the libraries, and registers it in the workspaces of the root package.json.
+//
+// Usage:
+//
+//	go run ./scripts/new-workspace <path> <name>
+//
+// For example, `go run ./scripts/new-workspace timeseries timeseries` creates the package @perses-dev/timeseries in ./timeseries.
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		logrus.Fatal("path and name arguments are required. Usage: new-workspace <path> <name>")
+	}
+	workspacePath := filepath.ToSlash(filepath.Clean(flag.Arg(0)))
+	name := flag.Arg(1)
+	if !strings.HasPrefix(name, scope) {
+		name = scope + name
+	}
+
+	repositoryURL, err := getRepositoryURL()
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to determine the repository URL")
+	}
+
+	pkg := newWorkspacePackage(name, npm.MustGetVersion("."), repositoryURL)
+	if err := scaffold(workspacePath, pkg); err != nil {
+		logrus.WithError(err).Fatalf("unable to scaffold the workspace %s", workspacePath)
+	}
+	if err := addWorkspace(workspacePath); err != nil {
+		logrus.WithError(err).Fatalf("unable to add %s to the root workspaces", workspacePath)
+	}
+
+	logrus.Infof("✓ Created workspace %s for package %s@%s", workspacePath, pkg.Name, pkg.Version)
+}
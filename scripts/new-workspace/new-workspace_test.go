@@ -0,0 +1,155 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestGetRepositoryURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		remote  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "ssh remote",
+			remote: "git@github.com:perses/shared.git",
+			want:   "https://github.com/perses/shared",
+		},
+		{
+			name:   "https remote",
+			remote: "https://github.com/perses/shared",
+			want:   "https://github.com/perses/shared",
+		},
+		{
+			name:    "not a GitHub remote",
+			remote:  "https://gitlab.com/perses/shared.git",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			testutil.Git(t, "remote", "add", "origin", test.remote)
+			got, err := getRepositoryURL()
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("got %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScaffold(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := scaffold("timeseries", newWorkspacePackage("@perses-dev/timeseries", "0.53.0", "https://github.com/perses/shared")); err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+  "name": "@perses-dev/timeseries",
+  "version": "0.53.0",
+  "description": "",
+  "license": "Apache-2.0",
+  "homepage": "https://github.com/perses/shared/blob/main/README.md",
+  "repository": {
+    "type": "git",
+    "url": "git+https://github.com/perses/shared.git"
+  },
+  "bugs": {
+    "url": "https://github.com/perses/shared/issues"
+  },
+  "module": "dist/index.js",
+  "main": "dist/cjs/index.js",
+  "types": "dist/index.d.ts",
+  "files": [
+    "dist"
+  ]
+}
+`
+	if got := testutil.ReadFile(t, "timeseries/package.json"); got != want {
+		t.Errorf("unexpected package.json:\n%s", got)
+	}
+	if err := scaffold("timeseries", newWorkspacePackage("@perses-dev/timeseries", "0.53.0", "https://github.com/perses/shared")); err == nil {
+		t.Error("expected an existing package.json not to be overwritten")
+	}
+}
+
+func TestAddWorkspace(t *testing.T) {
+	const root = `{
+  "name": "perses-shared",
+  "private": true,
+  "scripts": {
+    "build": "turbo run build"
+  },
+  "workspaces": [
+    "core",
+    "components"
+  ],
+  "devDependencies": {
+    "turbo": "^2.0.0"
+  }
+}
+`
+	tests := []struct {
+		name      string
+		workspace string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "new workspace appended",
+			workspace: "timeseries",
+			want: `{
+  "name": "perses-shared",
+  "private": true,
+  "scripts": {
+    "build": "turbo run build"
+  },
+  "workspaces": [
+    "core",
+    "components",
+    "timeseries"
+  ],
+  "devDependencies": {
+    "turbo": "^2.0.0"
+  }
+}
+`,
+		},
+		{
+			name:      "existing workspace",
+			workspace: "core",
+			want:      root,
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{"package.json": root})
+			if err := addWorkspace(test.workspace); (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if got := testutil.ReadFile(t, "package.json"); got != test.want {
+				t.Errorf("unexpected root package.json:\n%s", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/diff"
+	"github.com/perses/shared/scripts/pkgjson"
+	"github.com/sirupsen/logrus"
+)
+
+// fieldOrder is the canonical order of the package.json fields. Fields not listed here are kept after them, in their original order.
+var fieldOrder = []string{
+	"name",
+	"version",
+	"description",
+	"private",
+	"license",
+	"homepage",
+	"repository",
+	"bugs",
+	"type",
+	"module",
+	"main",
+	"types",
+	"exports",
+	"sideEffects",
+	"bin",
+	"scripts",
+	"workspaces",
+	"dependencies",
+	"devDependencies",
+	"peerDependencies",
+	"peerDependenciesMeta",
+	"optionalDependencies",
+	"files",
+	"publishConfig",
+	"engines",
+	"packageManager",
+}
+
+// normalize returns the current and the normalized content of the package.json of the given directory.
+func normalize(dirPath string) ([]byte, []byte, error) {
+	original, err := os.ReadFile(filepath.Join(dirPath, "package.json")) //nolint: gosec
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := pkgjson.Parse(original)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj.Reorder(fieldOrder)
+	normalized, err := obj.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	return original, normalized, nil
+}
+
+// This script rewrites the package.json of the root and of every workspace with a canonical field order
+// and a two-space indentation, so that they don't drift apart and produce noisy diffs.
+//
+// Usage:
+//
+//	go run ./scripts/normalize-pkg
+//
+// To only check the files are normalized, without modifying them (e.g. in the CI):
+//
+//	go run ./scripts/normalize-pkg --check
+func main() {
+	check := flag.Bool("check", false, "Fail if a package.json is not normalized instead of rewriting it")
+	flag.Parse()
+
+	dirs := append([]string{"."}, npm.MustGetWorkspaces(".")...)
+	var notNormalized []string
+	for _, dir := range dirs {
+		pkgPath := filepath.Join(dir, "package.json")
+		original, normalized, err := normalize(dir)
+		if err != nil {
+			logrus.WithError(err).Fatalf("unable to normalize %s", pkgPath)
+		}
+		if bytes.Equal(original, normalized) {
+			logrus.Debugf("%s is already normalized", pkgPath)
+			continue
+		}
+		if *check {
+			logrus.Errorf("%s is not normalized", pkgPath)
+			fmt.Print(diff.Unified(pkgPath, original, normalized))
+			notNormalized = append(notNormalized, pkgPath)
+			continue
+		}
+		if err := os.WriteFile(pkgPath, normalized, 0644); err != nil { // nolint: gosec
+			logrus.WithError(err).Fatalf("unable to write the file %s", pkgPath)
+		}
+		logrus.Infof("✓ Normalized %s", pkgPath)
+	}
+
+	if len(notNormalized) > 0 {
+		logrus.Fatalf("%d package.json file(s) not normalized, run `go run ./scripts/normalize-pkg` to fix them", len(notNormalized))
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+const normalizedPackage = `{
+  "name": "@perses-dev/core",
+  "version": "0.1.0",
+  "license": "Apache-2.0",
+  "main": "dist/cjs/index.js",
+  "scripts": {
+    "build": "tsc"
+  },
+  "dependencies": {
+    "lodash": "^4.17.21"
+  },
+  "files": [
+    "dist"
+  ],
+  "custom": true
+}
+`
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		wantNormalized bool
+	}{
+		{
+			name:           "already normalized",
+			content:        normalizedPackage,
+			wantNormalized: true,
+		},
+		{
+			name: "fields out of order",
+			content: `{
+  "dependencies": {
+    "lodash": "^4.17.21"
+  },
+  "name": "@perses-dev/core",
+  "custom": true,
+  "files": [
+    "dist"
+  ],
+  "version": "0.1.0",
+  "scripts": {
+    "build": "tsc"
+  },
+  "main": "dist/cjs/index.js",
+  "license": "Apache-2.0"
+}
+`,
+		},
+		{
+			name:    "single line without final newline",
+			content: `{"name": "@perses-dev/core", "version": "0.1.0", "license": "Apache-2.0", "main": "dist/cjs/index.js", "scripts": {"build": "tsc"}, "dependencies": {"lodash": "^4.17.21"}, "files": ["dist"], "custom": true}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			testutil.WriteFiles(t, dir, map[string]string{"package.json": test.content})
+			original, normalized, err := normalize(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(normalized) != normalizedPackage {
+				t.Errorf("unexpected normalized content:\n%s", normalized)
+			}
+			// --check fails on the files whose normalized content differs
+			if isNormalized := bytes.Equal(original, normalized); isNormalized != test.wantNormalized {
+				t.Errorf("got normalized %t, expected %t", isNormalized, test.wantNormalized)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/perses/perses/scripts/pkg/changelog"
+	"github.com/perses/shared/scripts/semver"
+)
+
+// SubjectTransform is a regex-replace applied to each commit subject before it's added to the changelog.
+type SubjectTransform struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// SubjectTransforms is a flag that can be repeated to declare an ordered list of transforms,
+// each of them in the format `<regex>=><replacement>`.
+type SubjectTransforms []SubjectTransform
+
+func (t *SubjectTransforms) String() string {
+	var transforms []string
+	for _, transform := range *t {
+		transforms = append(transforms, fmt.Sprintf("%s=>%s", transform.Pattern, transform.Replacement))
+	}
+	return strings.Join(transforms, ", ")
+}
+
+func (t *SubjectTransforms) Set(value string) error {
+	pattern, replacement, found := strings.Cut(value, "=>")
+	if !found {
+		return fmt.Errorf("invalid transform %q, expected format: <regex>=><replacement>", value)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid transform regex %q: %w", pattern, err)
+	}
+	*t = append(*t, SubjectTransform{Pattern: re, Replacement: replacement})
+	return nil
+}
+
+// Apply runs every transform, in order, on the subject of each entry. Entries are in the `<commit> <subject>` format.
+func (t SubjectTransforms) Apply(entries []string) []string {
+	result := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		commit, subject, _ := strings.Cut(entry, " ")
+		for _, transform := range t {
+			subject = transform.Pattern.ReplaceAllString(subject, transform.Replacement)
+		}
+		result = append(result, fmt.Sprintf("%s %s", commit, strings.TrimSpace(subject)))
+	}
+	return result
+}
+
+// Changelog is the machine-readable form of a changelog.
+type Changelog struct {
+	Features        []string `json:"features,omitempty"`
+	Enhancements    []string `json:"enhancements,omitempty"`
+	BugFixes        []string `json:"bugFixes,omitempty"`
+	BreakingChanges []string `json:"breakingChanges,omitempty"`
+	Docs            []string `json:"docs,omitempty"`
+	Unknown         []string `json:"unknown,omitempty"`
+}
+
+// catalogEntries are the catalog entries a changelog can be filtered on.
+var catalogEntries = []string{"FEATURE", "ENHANCEMENT", "BUGFIX", "BREAKINGCHANGE", "DOC"}
+
+// TypeFilter keeps or drops the changelog sections by catalog entry.
+// Include and Exclude are mutually exclusive, and an empty filter keeps everything.
+type TypeFilter struct {
+	Include []string
+	Exclude []string
+}
+
+func parseTypes(value string) []string {
+	var types []string
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// RegisterFlags registers the --include-types and --exclude-types flags.
+func (f *TypeFilter) RegisterFlags() {
+	flag.Func("include-types", "Comma-separated list of catalog entries to keep in the changelog, e.g. FEATURE,BUGFIX", func(value string) error {
+		f.Include = parseTypes(value)
+		return nil
+	})
+	flag.Func("exclude-types", "Comma-separated list of catalog entries to drop from the changelog, e.g. DOC", func(value string) error {
+		f.Exclude = parseTypes(value)
+		return nil
+	})
+}
+
+// Validate checks the filter uses known catalog entries and doesn't both include and exclude.
+func (f TypeFilter) Validate() error {
+	if len(f.Include) > 0 && len(f.Exclude) > 0 {
+		return fmt.Errorf("--include-types and --exclude-types are mutually exclusive")
+	}
+	for _, t := range append(slices.Clone(f.Include), f.Exclude...) {
+		if !slices.Contains(catalogEntries, t) {
+			return fmt.Errorf("unknown catalog entry %q, expected one of %s", t, strings.Join(catalogEntries, ", "))
+		}
+	}
+	return nil
+}
+
+func (f TypeFilter) keep(catalogEntry string) bool {
+	if len(f.Include) > 0 {
+		return slices.Contains(f.Include, catalogEntry)
+	}
+	return !slices.Contains(f.Exclude, catalogEntry)
+}
+
+// generate builds the changelog of the given git log entries, without the sections dropped by the filter.
+// Uncategorized entries are dropped as well when an allowlist is used.
+func generate(entries []string, filter TypeFilter) *changelog.Changelog {
+	clog := changelog.New(entries)
+	sections := map[string]*[]string{
+		"FEATURE":        &clog.Features,
+		"ENHANCEMENT":    &clog.Enhancements,
+		"BUGFIX":         &clog.BugFixes,
+		"BREAKINGCHANGE": &clog.BreakingChanges,
+		"DOC":            &clog.Docs,
+	}
+	for catalogEntry, section := range sections {
+		if !filter.keep(catalogEntry) {
+			*section = nil
+		}
+	}
+	if len(filter.Include) > 0 {
+		clog.Unknown = nil
+	}
+	return clog
+}
+
+// unclosedLinkPattern matches a link whose URL is never closed, e.g. `[docs](https://perses.dev`.
+var unclosedLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\(([^)]*)$`)
+
+// escapeMarkdown escapes the constructs of a commit subject that would break the rendering of the rest of the changelog:
+// code fences, unbalanced inline code and unclosed links.
+func escapeMarkdown(subject string) string {
+	subject = strings.ReplaceAll(subject, "```", "\\`\\`\\`")
+	// an odd number of unescaped backticks leaves an inline code span open
+	if unescaped := strings.Count(subject, "`") - strings.Count(subject, "\\`"); unescaped%2 == 1 {
+		i := strings.LastIndex(subject, "`")
+		subject = subject[:i] + "\\" + subject[i:]
+	}
+	return unclosedLinkPattern.ReplaceAllString(subject, `\[$1\]($2`)
+}
+
+// Markdown generates the markdown changelog of the given git log entries.
+// Commit subjects are escaped so that a stray code fence or link doesn't break the rendering of the following entries.
+func Markdown(entries []string, filter TypeFilter) string {
+	escaped := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		commit, subject, _ := strings.Cut(entry, " ")
+		escaped = append(escaped, fmt.Sprintf("%s %s", commit, escapeMarkdown(subject)))
+	}
+	return generate(escaped, filter).GenerateChangelog()
+}
+
+// Uncategorized returns the entries that have no catalog entry and therefore don't appear in the markdown changelog.
+func Uncategorized(entries []string) []string {
+	return changelog.New(entries).Unknown
+}
+
+// UserFacing returns the entries that document a user-facing change: features, enhancements, bug fixes and breaking changes.
+func UserFacing(entries []string) []string {
+	clog := changelog.New(entries)
+	return slices.Concat(clog.Features, clog.Enhancements, clog.BugFixes, clog.BreakingChanges)
+}
+
+// JSON generates the JSON changelog of the given git log entries.
+func JSON(entries []string, filter TypeFilter) ([]byte, error) {
+	clog := generate(entries, filter)
+	return json.MarshalIndent(Changelog{
+		Features:        clog.Features,
+		Enhancements:    clog.Enhancements,
+		BugFixes:        clog.BugFixes,
+		BreakingChanges: clog.BreakingChanges,
+		Docs:            clog.Docs,
+		Unknown:         clog.Unknown,
+	}, "", "  ")
+}
+
+// RequiredBump returns the minimum bump the given git log entries require: major for a breaking change, minor for a
+// feature and patch for anything else. Before 1.0.0, breaking changes only require a minor bump.
+func RequiredBump(entries []string, from semver.Version) semver.Bump {
+	if len(entries) == 0 {
+		return semver.BumpNone
+	}
+	clog := changelog.New(entries)
+	switch {
+	case len(clog.BreakingChanges) > 0 && from.Major > 0:
+		return semver.BumpMajor
+	case len(clog.BreakingChanges) > 0 || len(clog.Features) > 0:
+		return semver.BumpMinor
+	default:
+		return semver.BumpPatch
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notes
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/semver"
+)
+
+func TestRequiredBump(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		from    string
+		want    semver.Bump
+	}{
+		{
+			name: "no commit",
+			from: "1.0.0",
+			want: semver.BumpNone,
+		},
+		{
+			name:    "fixes and chores",
+			entries: []string{"a1b2c3d [BUGFIX] fix the panel header", "b2c3d4e [IGNORE] bump dependencies"},
+			from:    "1.0.0",
+			want:    semver.BumpPatch,
+		},
+		{
+			name:    "feature",
+			entries: []string{"a1b2c3d [FEATURE] add a markdown panel", "b2c3d4e [BUGFIX] fix the panel header"},
+			from:    "1.0.0",
+			want:    semver.BumpMinor,
+		},
+		{
+			name:    "breaking change",
+			entries: []string{"a1b2c3d [BREAKINGCHANGE] remove the legacy layout", "b2c3d4e [FEATURE] add a markdown panel"},
+			from:    "1.0.0",
+			want:    semver.BumpMajor,
+		},
+		{
+			name:    "breaking change before 1.0.0",
+			entries: []string{"a1b2c3d [BREAKINGCHANGE] remove the legacy layout"},
+			from:    "0.53.0",
+			want:    semver.BumpMinor,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			from, err := semver.Parse(test.from)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := RequiredBump(test.entries, from); got != test.want {
+				t.Errorf("got %s, expected %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSubjectTransforms(t *testing.T) {
+	tests := []struct {
+		name       string
+		transforms []string
+		entries    []string
+		want       []string
+	}{
+		{
+			name:       "link ticket IDs",
+			transforms: []string{`#(\d+)=>[#$1](https://github.com/perses/shared/issues/$1)`},
+			entries:    []string{"a1b2c3d [BUGFIX] fix the panel header (#123)"},
+			want:       []string{"a1b2c3d [BUGFIX] fix the panel header ([#123](https://github.com/perses/shared/issues/123))"},
+		},
+		{
+			name:       "strip a noise prefix",
+			transforms: []string{`^(\[[A-Z]+\]) (?:chore\(deps\): |ui: )=>$1 `},
+			entries:    []string{"a1b2c3d [ENHANCEMENT] ui: improve the legend", "b2c3d4e [IGNORE] chore(deps): bump lodash", "c3d4e5f [FEATURE] add a panel"},
+			want:       []string{"a1b2c3d [ENHANCEMENT] improve the legend", "b2c3d4e [IGNORE] bump lodash", "c3d4e5f [FEATURE] add a panel"},
+		},
+		{
+			name:       "transforms applied in order",
+			transforms: []string{`PERSES-(\d+)=>#$1`, `#(\d+)=>[#$1](https://github.com/perses/shared/issues/$1)`},
+			entries:    []string{"a1b2c3d [FEATURE] add a panel PERSES-7"},
+			want:       []string{"a1b2c3d [FEATURE] add a panel [#7](https://github.com/perses/shared/issues/7)"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var transforms SubjectTransforms
+			for _, transform := range test.transforms {
+				if err := transforms.Set(transform); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := transforms.Apply(test.entries); !slices.Equal(got, test.want) {
+				t.Errorf("got %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSubjectTransformsSetInvalid(t *testing.T) {
+	for _, value := range []string{"no separator", "([a-z]=>x"} {
+		var transforms SubjectTransforms
+		if err := transforms.Set(value); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
+
+func TestTypeFilter(t *testing.T) {
+	entries := []string{
+		"a1b2c3d [FEATURE] add a markdown panel",
+		"b2c3d4e [BUGFIX] fix the panel header",
+		"c3d4e5f [DOC] document the legend",
+		"d4e5f6a chore: bump dependencies",
+	}
+	tests := []struct {
+		name   string
+		filter TypeFilter
+		want   Changelog
+	}{
+		{
+			name: "no filter",
+			want: Changelog{
+				Features: []string{"add a markdown panel"},
+				BugFixes: []string{"fix the panel header"},
+				Docs:     []string{"document the legend"},
+				Unknown:  []string{"chore: bump dependencies"},
+			},
+		},
+		{
+			name:   "allowlist keeps features and fixes only",
+			filter: TypeFilter{Include: []string{"FEATURE", "BUGFIX"}},
+			want: Changelog{
+				Features: []string{"add a markdown panel"},
+				BugFixes: []string{"fix the panel header"},
+			},
+		},
+		{
+			name:   "denylist drops the docs",
+			filter: TypeFilter{Exclude: []string{"DOC"}},
+			want: Changelog{
+				Features: []string{"add a markdown panel"},
+				BugFixes: []string{"fix the panel header"},
+				Unknown:  []string{"chore: bump dependencies"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := JSON(entries, test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got Changelog
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, expected %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTypeFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  TypeFilter
+		wantErr string
+	}{
+		{
+			name:   "allowlist",
+			filter: TypeFilter{Include: parseTypes("feature, bugfix")},
+		},
+		{
+			name:    "conflicting flags",
+			filter:  TypeFilter{Include: []string{"FEATURE"}, Exclude: []string{"DOC"}},
+			wantErr: "--include-types and --exclude-types are mutually exclusive",
+		},
+		{
+			name:    "unknown catalog entry",
+			filter:  TypeFilter{Exclude: parseTypes("chore")},
+			wantErr: `unknown catalog entry "CHORE"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.filter.Validate()
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{
+			name:    "plain subject",
+			subject: "[BUGFIX] fix the panel header",
+			want:    "[BUGFIX] fix the panel header",
+		},
+		{
+			name:    "stray code fence",
+			subject: "[BUGFIX] render ``` in markdown panels",
+			want:    "[BUGFIX] render \\`\\`\\` in markdown panels",
+		},
+		{
+			name:    "balanced inline code",
+			subject: "[FEATURE] add the `unit` option",
+			want:    "[FEATURE] add the `unit` option",
+		},
+		{
+			name:    "unbalanced inline code",
+			subject: "[FEATURE] add the `unit option",
+			want:    "[FEATURE] add the \\`unit option",
+		},
+		{
+			name:    "closed link",
+			subject: "[DOC] link the [docs](https://perses.dev)",
+			want:    "[DOC] link the [docs](https://perses.dev)",
+		},
+		{
+			name:    "unclosed link",
+			subject: "[DOC] link the [docs](https://perses.dev",
+			want:    "[DOC] link the \\[docs\\](https://perses.dev",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := escapeMarkdown(test.subject); got != test.want {
+				t.Errorf("got %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownStrayFence(t *testing.T) {
+	got := Markdown([]string{"a1b2c3d [BUGFIX] render ``` in markdown panels", "b2c3d4e [BUGFIX] fix the panel header"}, TypeFilter{})
+	if strings.Contains(got, "render ```") {
+		t.Errorf("the code fence isn't escaped, it would swallow the following entries:\n%s", got)
+	}
+	if !strings.Contains(got, "fix the panel header") {
+		t.Errorf("missing entry following the code fence:\n%s", got)
+	}
+}
@@ -21,17 +21,19 @@ import (
 	"regexp"
 
 	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/diff"
 	"github.com/sirupsen/logrus"
 )
 
 var versionPattern = regexp.MustCompile(`^\d+\.\d+\.\d+(?:-[\w\d.]+)?$`)
 
-func updatePackageVersion(workspaces []string, workspacePath string, newVersion string) error {
+func updatePackageVersion(workspaces []string, workspacePath string, newVersion string, dryRun bool) error {
 	pkgPath := filepath.Join(workspacePath, "package.json")
-	data, err := os.ReadFile(pkgPath)
+	original, err := os.ReadFile(pkgPath)
 	if err != nil {
 		logrus.WithError(err).Fatalf("unable to read the file %s", pkgPath)
 	}
+	data := original
 
 	// First, update the package version in the package.json
 	bumpVersion := regexp.MustCompile(`"version":\s*"[0-9]+\.[0-9]+\.[0-9]+(-(alpha|beta|rc)\.[0-9]+)?"`)
@@ -42,6 +44,10 @@ func updatePackageVersion(workspaces []string, workspacePath string, newVersion
 		bumpNPMDeps := regexp.MustCompile(fmt.Sprintf(`"@perses-dev/%s":\s*"(\^)?[0-9]+\.[0-9]+\.[0-9]+(-(alpha|beta|rc)\.[0-9]+)?"`, workspace))
 		data = bumpNPMDeps.ReplaceAll(data, []byte(fmt.Sprintf(`"@perses-dev/%s": "%s"`, workspace, newVersion)))
 	}
+	if dryRun {
+		fmt.Print(diff.Unified(pkgPath, original, data))
+		return nil
+	}
 	if writeErr := os.WriteFile(pkgPath, data, 0644); writeErr != nil {
 		logrus.WithError(writeErr).Fatalf("unable to write the file %s", pkgPath)
 	}
@@ -49,6 +55,7 @@ func updatePackageVersion(workspaces []string, workspacePath string, newVersion
 }
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "Print the changes as a unified diff instead of writing them")
 	flag.Parse()
 
 	if len(flag.Args()) == 0 {
@@ -68,18 +75,24 @@ func main() {
 	}
 
 	// First, update the root package.json
-	if err := updatePackageVersion(workspaces, ".", version); err != nil {
+	if err := updatePackageVersion(workspaces, ".", version, *dryRun); err != nil {
 		logrus.WithError(err).Fatal("failed to update root package.json")
 	}
 
 	logrus.Infof("Updating %d workspace(s) to version %s", len(workspaces), version)
 
 	for _, workspace := range workspaces {
-		if err := updatePackageVersion(workspaces, workspace, version); err != nil {
+		if err := updatePackageVersion(workspaces, workspace, version, *dryRun); err != nil {
 			logrus.WithError(err).Fatalf("failed to update workspace: %s", workspace)
 		}
-		logrus.Infof("✓ Updated %s to version %s", workspace, version)
+		if !*dryRun {
+			logrus.Infof("✓ Updated %s to version %s", workspace, version)
+		}
 	}
 
+	if *dryRun {
+		logrus.Info("Dry run, no file has been modified")
+		return
+	}
 	logrus.Info("All workspace packages updated successfully")
 }
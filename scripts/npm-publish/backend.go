@@ -0,0 +1,79 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// packageManagers are the package managers that can be selected with -pm to publish the tarballs.
+// The other npm commands (pack, view, access...) only query the registry and keep using npm.
+var packageManagers = []string{"npm", "pnpm", "yarn"}
+
+// publishCommand returns the command publishing the tarball to the registry with the package manager of the options,
+// or nil when the package manager can't run it, i.e. a dry run with yarn, which has no such mode.
+func publishCommand(packed tarball, registry string, otp string, opts publishOptions) (*exec.Cmd, error) {
+	args := []string{"publish", packed.Path, "--access", "public", "--registry", registry, "--tag", opts.tag}
+	if otp != "" {
+		args = append(args, "--otp", otp)
+	}
+	switch opts.pm {
+	case "npm":
+		if opts.dryRun {
+			args = append(args, "--dry-run")
+		}
+	case "pnpm":
+		// the tarball is already built, the state of the git repository doesn't matter
+		args = append(args, "--no-git-checks")
+		if opts.dryRun {
+			args = append(args, "--dry-run")
+		}
+	case "yarn":
+		if opts.dryRun {
+			return nil, nil
+		}
+		args = append(args, "--non-interactive")
+	default:
+		return nil, fmt.Errorf("unsupported package manager %q, expected one of %v", opts.pm, packageManagers)
+	}
+	return exec.Command(opts.pm, args...), nil
+}
+
+// packCommand returns the command packing the package of the current directory into the tarball path with the package manager pm.
+// npm and pnpm name the tarball themselves, the same way as tarballName does.
+func packCommand(path string, pm string) (*exec.Cmd, error) {
+	switch pm {
+	case "npm", "pnpm":
+		return exec.Command(pm, "pack", "--pack-destination", filepath.Dir(path)), nil
+	case "yarn":
+		return exec.Command(pm, "pack", "--filename", path), nil
+	default:
+		return nil, fmt.Errorf("unsupported package manager %q, expected one of %v", pm, packageManagers)
+	}
+}
+
+// whoamiCommand returns the command printing the user the package manager pm is authenticated as on the registry.
+// yarn has no such command, but it publishes with the credentials of the npm configuration, which npm can check.
+func whoamiCommand(registry string, pm string) (*exec.Cmd, error) {
+	switch pm {
+	case "npm", "pnpm":
+		return exec.Command(pm, "whoami", "--registry", registry), nil
+	case "yarn":
+		return exec.Command("npm", "whoami", "--registry", registry), nil
+	default:
+		return nil, fmt.Errorf("unsupported package manager %q, expected one of %v", pm, packageManagers)
+	}
+}
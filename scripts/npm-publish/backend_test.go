@@ -0,0 +1,123 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	const registry = "https://registry.example.com/"
+	packed := tarball{Path: "/tmp/pack/perses-dev-core-1.0.0.tgz"}
+	opts := publishOptions{tag: "latest"}
+	tests := []struct {
+		pm          string
+		wantPublish []string
+		wantDryRun  []string
+		wantPack    []string
+		wantWhoami  []string
+	}{
+		{
+			pm:          "npm",
+			wantPublish: []string{"npm", "publish", packed.Path, "--access", "public", "--registry", registry, "--tag", "latest", "--otp", "123456"},
+			wantDryRun:  []string{"npm", "publish", packed.Path, "--access", "public", "--registry", registry, "--tag", "latest", "--otp", "123456", "--dry-run"},
+			wantPack:    []string{"npm", "pack", "--pack-destination", "/tmp/pack"},
+			wantWhoami:  []string{"npm", "whoami", "--registry", registry},
+		},
+		{
+			pm:          "pnpm",
+			wantPublish: []string{"pnpm", "publish", packed.Path, "--access", "public", "--registry", registry, "--tag", "latest", "--otp", "123456", "--no-git-checks"},
+			wantDryRun:  []string{"pnpm", "publish", packed.Path, "--access", "public", "--registry", registry, "--tag", "latest", "--otp", "123456", "--no-git-checks", "--dry-run"},
+			wantPack:    []string{"pnpm", "pack", "--pack-destination", "/tmp/pack"},
+			wantWhoami:  []string{"pnpm", "whoami", "--registry", registry},
+		},
+		{
+			pm:          "yarn",
+			wantPublish: []string{"yarn", "publish", packed.Path, "--access", "public", "--registry", registry, "--tag", "latest", "--otp", "123456", "--non-interactive"},
+			wantPack:    []string{"yarn", "pack", "--filename", packed.Path},
+			wantWhoami:  []string{"npm", "whoami", "--registry", registry},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.pm, func(t *testing.T) {
+			pmOpts := opts
+			pmOpts.pm = test.pm
+			publish, err := publishCommand(packed, registry, "123456", pmOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(publish.Args, test.wantPublish) {
+				t.Errorf("publish: got %q, expected %q", publish.Args, test.wantPublish)
+			}
+
+			pmOpts.dryRun = true
+			dryRun, err := publishCommand(packed, registry, "123456", pmOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantDryRun == nil {
+				if dryRun != nil {
+					t.Errorf("dry run: got %q, expected no command", dryRun.Args)
+				}
+			} else if dryRun == nil || !slices.Equal(dryRun.Args, test.wantDryRun) {
+				t.Errorf("dry run: got %v, expected %q", dryRun, test.wantDryRun)
+			}
+
+			pack, err := packCommand(packed.Path, test.pm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(pack.Args, test.wantPack) {
+				t.Errorf("pack: got %q, expected %q", pack.Args, test.wantPack)
+			}
+
+			whoami, err := whoamiCommand(registry, test.pm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(whoami.Args, test.wantWhoami) {
+				t.Errorf("whoami: got %q, expected %q", whoami.Args, test.wantWhoami)
+			}
+		})
+	}
+}
+
+func TestCommandsUnsupportedPackageManager(t *testing.T) {
+	if _, err := publishCommand(tarball{}, "", "", publishOptions{pm: "bun"}); err == nil {
+		t.Error("publish: expected an error")
+	}
+	if _, err := packCommand("", "bun"); err == nil {
+		t.Error("pack: expected an error")
+	}
+	if _, err := whoamiCommand("", "bun"); err == nil {
+		t.Error("whoami: expected an error")
+	}
+}
+
+func TestTarballName(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		want    string
+	}{
+		{name: "@perses-dev/components", version: "0.1.0", want: "perses-dev-components-0.1.0.tgz"},
+		{name: "perses", version: "1.0.0-beta.1", want: "perses-1.0.0-beta.1.tgz"},
+	}
+	for _, test := range tests {
+		if got := tarballName(test.name, test.version); got != test.want {
+			t.Errorf("tarballName(%q, %q) = %q, expected %q", test.name, test.version, got, test.want)
+		}
+	}
+}
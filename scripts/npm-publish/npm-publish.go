@@ -14,74 +14,274 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/config"
+	"github.com/perses/shared/scripts/lock"
+	"github.com/perses/shared/scripts/parallel"
+	"github.com/perses/shared/scripts/semver"
 	"github.com/perses/shared/scripts/tag"
+	"github.com/perses/shared/scripts/timing"
+	"github.com/perses/shared/scripts/workspace"
 	"github.com/sirupsen/logrus"
 )
 
-func publishPackage(workspacePath string, dryRun bool) error {
-	// Read package.json from workspace
-	pck, err := npm.GetPackage(workspacePath)
+// repeatedFlag is a flag that can be repeated, e.g. to publish to several registries.
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, ", ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// publishTarget is a registry and the workspaces to publish to it.
+type publishTarget struct {
+	registry   string
+	workspaces []string
+}
+
+// resolveTargets returns the registries to publish the workspaces to. The registries given with -registry apply to every workspace,
+// so that a staging run can be redirected as a whole. Otherwise, each workspace is published to the registry of the publishConfig
+// of its package.json, defaulting to the one configured in npm.
+func resolveTargets(workspaces []string, registries []string) ([]publishTarget, error) {
+	var targets []publishTarget
+	if len(registries) > 0 {
+		for _, registry := range registries {
+			targets = append(targets, publishTarget{registry: registry, workspaces: workspaces})
+		}
+		return targets, nil
+	}
+	defaultRegistry := ""
+	for _, workspace := range workspaces {
+		m, err := readManifest(workspace)
+		if err != nil {
+			return nil, err
+		}
+		registry := m.PublishConfig.Registry
+		if registry == "" {
+			if defaultRegistry == "" {
+				if defaultRegistry, err = getDefaultRegistry(); err != nil {
+					return nil, err
+				}
+			}
+			registry = defaultRegistry
+		}
+		i := slices.IndexFunc(targets, func(t publishTarget) bool { return t.registry == registry })
+		if i < 0 {
+			targets = append(targets, publishTarget{registry: registry})
+			i = len(targets) - 1
+		}
+		targets[i].workspaces = append(targets[i].workspaces, workspace)
+	}
+	return targets, nil
+}
+
+// distTag returns the dist-tag to publish the version under: latest for a release, and for a prerelease, the kind of prerelease
+// when it's alpha or beta, next otherwise (e.g. for a release candidate), so that a prerelease never becomes the default install.
+func distTag(version string) (string, error) {
+	v, err := semver.Parse(version)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if !v.IsPrerelease() {
+		return "latest", nil
 	}
+	switch v.Prerelease[0] {
+	case "alpha", "beta":
+		return v.Prerelease[0], nil
+	default:
+		return "next", nil
+	}
+}
 
-	// Get the dist directory path
-	libraryPath := filepath.Join(workspacePath, "dist")
+// publishOptions are the options of the publication shared by every workspace.
+type publishOptions struct {
+	// tag is the dist-tag to publish under.
+	tag string
+	// otp provides the one-time passwords of an account protected by 2FA, if any.
+	otp    *otpSource
+	dryRun bool
+	// pm is the package manager publishing the tarballs, one of packageManagers.
+	pm string
+	// availabilityTimeout is the time given to the registry to serve a published version, zero disabling the check.
+	availabilityTimeout time.Duration
+}
 
-	// Get absolute path to return to later
-	originalDir, err := os.Getwd()
+// publishPackage publishes the packed tarball of a workspace to the registry.
+// Publishing the tarball rather than the dist directory keeps the working directory untouched, and guarantees that every registry
+// gets the same artifact.
+func publishPackage(packed tarball, registry string, opts publishOptions) error {
+	// validate the options before generating a one-time password for nothing
+	cmd, err := publishCommand(packed, registry, "", opts)
 	if err != nil {
 		return err
 	}
-
-	// Change to the dist directory
-	if err := os.Chdir(libraryPath); err != nil {
+	if cmd == nil {
+		logrus.Infof("%s has no dry run mode, skipping the publication of %s", opts.pm, packed.Path)
+		return nil
+	}
+	output, err := opts.otp.run(func(otp string) *exec.Cmd {
+		cmd, _ := publishCommand(packed, registry, otp, opts)
+		return cmd
+	})
+	if errors.Is(err, errOTPRejected) {
 		return err
 	}
-
-	// Prepare the npm publish command
-	args := []string{"publish", "--access", "public"}
-	if dryRun {
-		args = append(args, "--dry-run")
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, strings.TrimSpace(string(output)))
 	}
+	logrus.Debugf("Output of %s publish %s:\n%s", opts.pm, packed.Path, string(output))
+	return nil
+}
 
-	cmd := exec.Command("npm", args...)
-	output, execErr := cmd.CombinedOutput()
-
-	// Change back to original directory
-	if chdirErr := os.Chdir(originalDir); chdirErr != nil {
-		logrus.WithError(chdirErr).Warnf("unable to change back to original directory")
+// publishOrder returns the directories of the workspaces ordered so that each workspace is published after the workspaces
+// it depends on, so that a package never goes live before its dependencies are installable.
+// It also returns the directories of the internal dependencies of each workspace, by directory.
+func publishOrder(patterns []string) ([]string, map[string][]string, error) {
+	workspaces, err := workspace.Load(patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+	names, err := workspace.TopologicalOrder(workspaces)
+	if err != nil {
+		return nil, nil, err
+	}
+	dirs := make(map[string]string, len(workspaces))
+	for _, w := range workspaces {
+		dirs[w.Name] = w.Dir
+	}
+	ordered := make([]string, 0, len(names))
+	for _, name := range names {
+		ordered = append(ordered, dirs[name])
+	}
+	dependencies := make(map[string][]string, len(workspaces))
+	for _, w := range workspaces {
+		for _, name := range w.InternalDependencies {
+			dependencies[w.Dir] = append(dependencies[w.Dir], dirs[name])
+		}
 	}
+	return ordered, dependencies, nil
+}
 
-	if execErr != nil {
-		return execErr
+// publishWorkspace publishes the workspace to the registry, unless its version is already there,
+// then verifies it can be installed when verifyInstall is true. It returns true when the publication was skipped.
+func publishWorkspace(workspace string, packed tarball, registry string, opts publishOptions, verifyInstall bool, report *timing.Report) (bool, error) {
+	// A version can't be published twice: skipping the ones already there makes re-running a partially failed release safe
+	pck, err := npm.GetPackage(workspace)
+	if err != nil {
+		return false, fmt.Errorf("unable to read package.json for workspace %s: %w", workspace, err)
+	}
+	published, err := isPublished(pck.Name, pck.Version, registry)
+	if err != nil {
+		return false, err
+	}
+	if published {
+		logrus.Infof("Skipping workspace %s: %s@%s is already published to %s", workspace, pck.Name, pck.Version, registry)
+		return true, nil
 	}
 
-	logrus.Infof("Package %s@%s published to npm. Output:\n%s", pck.Name, pck.Version, string(output))
-	return nil
+	logrus.Infof("Publishing workspace %s to %s", workspace, registry)
+	stop := report.Track(fmt.Sprintf("publish %s to %s", workspace, registry))
+	err = publishPackage(packed, registry, opts)
+	stop()
+	if err != nil {
+		return false, err
+	}
+	logrus.Infof("Package %s@%s published to %s under the %s dist-tag", pck.Name, pck.Version, registry, opts.tag)
+	if opts.availabilityTimeout > 0 && !opts.dryRun {
+		stop = report.Track(fmt.Sprintf("wait for %s on %s", workspace, registry))
+		err := waitAvailable(pck.Name, pck.Version, registry, opts.availabilityTimeout)
+		stop()
+		if err != nil {
+			return false, err
+		}
+	}
+	if verifyInstall && !opts.dryRun {
+		stop = report.Track(fmt.Sprintf("verify install of %s from %s", workspace, registry))
+		err := verifyInstallable(pck.Name, pck.Version, registry)
+		stop()
+		if err != nil {
+			return false, fmt.Errorf("published but not installable: %w", err)
+		}
+	}
+	return false, nil
 }
 
-func verifyVersions(workspaces []string, expectedVersion string) error {
-	var mismatches []string
-
+// filterWorkspaces returns the workspaces whose directory or package name matches one of the glob patterns, keeping their order.
+// Each pattern must match at least one workspace, so that a typo doesn't silently publish nothing.
+func filterWorkspaces(workspaces []string, patterns []string) ([]string, error) {
+	matched := make(map[string]bool, len(patterns))
+	var selected []string
 	for _, workspace := range workspaces {
 		pck, err := npm.GetPackage(workspace)
 		if err != nil {
-			return fmt.Errorf("unable to read package.json for workspace %s: %w", workspace, err)
+			return nil, fmt.Errorf("unable to read package.json for workspace %s: %w", workspace, err)
+		}
+		selectedWorkspace := false
+		for _, pattern := range patterns {
+			dirMatch, err := path.Match(pattern, filepath.ToSlash(workspace))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+			nameMatch, _ := path.Match(pattern, pck.Name)
+			if dirMatch || nameMatch {
+				matched[pattern] = true
+				selectedWorkspace = true
+			}
+		}
+		if selectedWorkspace {
+			selected = append(selected, workspace)
+		}
+	}
+	for _, pattern := range patterns {
+		if !matched[pattern] {
+			return nil, fmt.Errorf("no workspace matches %q", pattern)
 		}
+	}
+	return selected, nil
+}
+
+// verifyVersions reads the package.json of the workspaces concurrently.
+// The mismatches are reported sorted by workspace, whatever the order the reads complete in.
+func verifyVersions(workspaces []string, expectedVersion string, parallelism int) error {
+	type result struct {
+		workspace string
+		version   string
+		err       error
+	}
+	results := parallel.Map(parallelism, workspaces, func(workspace string) result {
+		pck, err := npm.GetPackage(workspace)
+		return result{workspace: workspace, version: pck.Version, err: err}
+	})
+	slices.SortFunc(results, func(a, b result) int {
+		return strings.Compare(a.workspace, b.workspace)
+	})
 
-		if pck.Version != expectedVersion {
-			mismatches = append(mismatches, fmt.Sprintf("%s (expected: %s, found: %s)", workspace, expectedVersion, pck.Version))
+	var mismatches []string
+	for _, r := range results {
+		if r.err != nil {
+			return fmt.Errorf("unable to read package.json for workspace %s: %w", r.workspace, r.err)
+		}
+
+		if r.version != expectedVersion {
+			mismatches = append(mismatches, fmt.Sprintf("%s (expected: %s, found: %s)", r.workspace, expectedVersion, r.version))
 		} else {
-			logrus.Infof("✓ Workspace %s version matches: %s", workspace, pck.Version)
+			logrus.Infof("✓ Workspace %s version matches: %s", r.workspace, r.version)
 		}
 	}
 
@@ -92,43 +292,279 @@ func verifyVersions(workspaces []string, expectedVersion string) error {
 	return nil
 }
 
+// publishTargets publishes the tarball of each workspace to its registries, registry after registry, and returns the outcome of
+// every publication along with the number of registries where some failed. A failure on one registry doesn't prevent publishing
+// to the next one. On a registry, the workspaces are published concurrently, each one once the workspaces it depends on are published.
+func publishTargets(targets []publishTarget, tarballs map[string]tarball, dependencies map[string][]string, opts publishOptions, verifyInstall bool, parallelism int, report *timing.Report) ([]publishEntry, int) {
+	failedRegistries := 0
+	var entries []publishEntry
+	for _, target := range targets {
+		registry := target.registry
+		var mutex sync.Mutex
+		skipped := make(map[string]bool)
+		results := parallel.Graph(parallelism, target.workspaces, func(workspace string) []string {
+			return dependencies[workspace]
+		}, func(workspace string) error {
+			skip, err := publishWorkspace(workspace, tarballs[workspace], registry, opts, verifyInstall, report)
+			mutex.Lock()
+			defer mutex.Unlock()
+			skipped[workspace] = skip
+			return err
+		})
+		var failures []string
+		for i, err := range results {
+			workspace := target.workspaces[i]
+			pck := npm.MustGetPackage(workspace)
+			entry := publishEntry{
+				Package:   pck.Name,
+				Version:   pck.Version,
+				Workspace: workspace,
+				Registry:  registry,
+				Tag:       opts.tag,
+				Shasum:    tarballs[workspace].Shasum,
+				Integrity: tarballs[workspace].Integrity,
+				Status:    statusPublished,
+				DryRun:    opts.dryRun,
+			}
+			switch {
+			case err != nil:
+				logrus.WithError(err).Errorf("failed to publish workspace %s to %s", workspace, registry)
+				failures = append(failures, workspace)
+				entry.Status = statusFailed
+				entry.Error = err.Error()
+			case skipped[workspace]:
+				entry.Status = statusSkipped
+			}
+			entries = append(entries, entry)
+		}
+		if len(failures) > 0 {
+			logrus.Errorf("✗ %s: failed to publish %d workspace(s): %v", registry, len(failures), failures)
+			failedRegistries++
+		} else {
+			logrus.Infof("✓ %s: all workspaces published", registry)
+		}
+	}
+	return entries, failedRegistries
+}
+
 func main() {
 	dryRun := flag.Bool("dry-run", false, "Perform a dry run without actually publishing")
+	verifyInstall := flag.Bool("verify-install", false, "Verify each package can be installed from the registry once published")
+	allowLowerVersion := flag.Bool("allow-lower-version", false, "Allow publishing a version lower than the latest one already published")
+	distTagFlag := flag.String("dist-tag", "", "Dist-tag to publish under, defaults to latest for a release, and to alpha, beta or next for a prerelease")
+	pm := flag.String("pm", "npm", fmt.Sprintf("Package manager packing and publishing the packages, one of %v", packageManagers))
+	availabilityTimeout := flag.Duration("availability-timeout", 5*time.Minute, "Time given to the registry to serve each published version before failing, 0 to disable the check")
+	rollbackFlag := flag.Bool("rollback", false, "When a workspace fails to publish, deprecate the version of the other workspaces on the same registry")
+	rollbackMessage := flag.String("rollback-message", "{version} was only partially released, use the follow-up release instead", "Deprecation message of -rollback, {version} being replaced by the version")
+	output := flag.String("output", "", "File to write the JSON summary of the publication of each workspace to, e.g. to attach it to the GitHub release")
+	otp := flag.String("otp", "", "One-time password of an npm account protected by 2FA, defaults to the NPM_OTP environment variable. It's only valid for about 30 seconds, prefer -otp-command for a release publishing several packages")
+	otpCommand := flag.String("otp-command", "", "Shell command printing a one-time password of an npm account protected by 2FA, run for each operation needing one, e.g. \"op item get npm --otp\"")
+	packDir := flag.String("pack-dir", "", "Directory to keep the published tarballs in, e.g. to attach them to the GitHub release. Defaults to a temporary directory")
+	checkTypesFlag := flag.Bool("check-types", false, "Type-check the types entry of each workspace with tsc before publishing")
+	maxFileSize := flag.Int64("max-file-size", 5, "Size in MB above which a file of dist is reported, as a warning or as an error with --strict")
 	tagFlag := tag.Flag()
+	config.RegisterFlags()
+	parallelism := config.ParallelFlag()
+	lockTimeout := lock.Flag()
+	var registries repeatedFlag
+	flag.Var(&registries, "registry", "Registry to publish every workspace to, overriding their publishConfig.registry and the npm configured one. Can be repeated to publish to several registries in sequence")
+	var workspaceFilters repeatedFlag
+	flag.Var(&workspaceFilters, "workspace", "Only publish the workspaces whose directory or package name matches this glob (e.g. dashboards or @perses-dev/*). Can be repeated")
 	flag.Parse()
 
+	if !slices.Contains(packageManagers, *pm) {
+		logrus.Fatalf("unsupported package manager %q, expected one of %v", *pm, packageManagers)
+	}
+	if *otp == "" {
+		*otp = os.Getenv("NPM_OTP")
+	}
+	otpSource, err := newOTPSource(*otp, *otpCommand)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	// Prevent concurrent release runs from racing on tags and registries
+	lock.MustAcquire(*lockTimeout)
+	defer lock.Release()
+
+	report := timing.New()
+	report.LogOnExit()
+
 	// Parse tag and get version (without 'v' prefix)
 	expectedVersion := tag.Parse(tagFlag)
 	logrus.Infof("Expected version from tag: %s", expectedVersion)
 
+	if err := verifyRootPrivate(); err != nil {
+		logrus.WithError(err).Fatal("root package verification failed")
+	}
+
 	// Get workspaces from root package.json
 	workspaces := npm.MustGetWorkspaces(".")
 	if len(workspaces) == 0 {
 		logrus.Fatal("no workspaces found in package.json")
 	}
 
-	logrus.Infof("Found %d workspace(s) to publish", len(workspaces))
+	allWorkspaces, dependencies, err := publishOrder(workspaces)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to order the workspaces by dependency")
+	}
+	workspaces = allWorkspaces
+	if len(workspaceFilters) > 0 {
+		if workspaces, err = filterWorkspaces(allWorkspaces, workspaceFilters); err != nil {
+			logrus.WithError(err).Fatal("invalid workspace filter")
+		}
+	}
+	logrus.Infof("Found %d workspace(s) to publish, in order: %s", len(workspaces), strings.Join(workspaces, ", "))
 
 	// Verify versions match the tag
 	logrus.Infof("Verifying workspace versions match tag version %s...", expectedVersion)
-	if err := verifyVersions(workspaces, expectedVersion); err != nil {
+	stop := report.Track("verify versions")
+	if err := verifyVersions(workspaces, expectedVersion, *parallelism); err != nil {
 		logrus.WithError(err).Fatal("version verification failed")
 	}
+	stop()
 	logrus.Info("✓ All workspace versions verified successfully!")
 
-	// Publish each workspace
-	var failures []string
+	stop = report.Track("lint files field")
+	if err := lintFiles(workspaces); err != nil {
+		logrus.WithError(err).Fatal("files field verification failed")
+	}
+	stop()
+
+	stop = report.Track("verify dist content")
+	if err := verifyDistContent(workspaces); err != nil {
+		logrus.WithError(err).Fatal("dist verification failed")
+	}
+	stop()
+
+	stop = report.Track("lint file sizes")
+	if err := lintFileSizes(workspaces, *maxFileSize); err != nil {
+		logrus.WithError(err).Fatal("file size verification failed")
+	}
+	stop()
+
+	if *checkTypesFlag {
+		logrus.Info("Type-checking the types entry of each workspace...")
+		stop = report.Track("check types")
+		if err := checkTypes(workspaces); err != nil {
+			logrus.WithError(err).Fatal("types verification failed")
+		}
+		stop()
+	}
+
+	targets, err := resolveTargets(workspaces, registries)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to determine the npm registries")
+	}
+	targetRegistries := make([]string, 0, len(targets))
+	publications := 0
+	for _, target := range targets {
+		targetRegistries = append(targetRegistries, target.registry)
+		logrus.Infof("Publishing %d workspace(s) to %s", len(target.workspaces), target.registry)
+		publications += len(target.workspaces)
+	}
+	if otpSource.static != "" && publications > 1 && !*dryRun {
+		if warnErr := config.Warnf("the one-time password is only valid for about 30 seconds, it may expire before the %d publications complete, use -otp-command to generate a fresh one for each of them", publications); warnErr != nil {
+			logrus.Fatal(warnErr)
+		}
+	}
+
+	stop = report.Track("verify peer dependencies")
+	if err := verifyPeerDependencies(allWorkspaces, targets); err != nil {
+		logrus.WithError(err).Fatal("peer dependencies verification failed")
+	}
+	stop()
+
+	// Fail early if the package manager is not able to publish, rather than on the first publish
+	if !*dryRun {
+		stop = report.Track("verify authentication")
+		if err := verifyAuthentication(targetRegistries, *pm); err != nil {
+			logrus.WithError(err).Fatalf("%s authentication check failed", *pm)
+		}
+		stop()
+
+		stop = report.Track("verify publish access")
+		if err := verifyPublishAccess(targets); err != nil {
+			logrus.WithError(err).Fatal("npm publish access check failed")
+		}
+		stop()
+	}
+
+	if !*allowLowerVersion {
+		stop = report.Track("verify published versions")
+		if err := verifyNotLowerThanPublished(targets, expectedVersion); err != nil {
+			logrus.WithError(err).Fatal("published version verification failed, use --allow-lower-version to publish anyway")
+		}
+		stop()
+	}
+
+	publishTag, err := distTag(expectedVersion)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to determine the dist-tag")
+	}
+	if *distTagFlag != "" {
+		if *distTagFlag == "latest" && publishTag != "latest" {
+			if warnErr := config.Warnf("publishing the prerelease %s under the latest dist-tag, it will be installed by default", expectedVersion); warnErr != nil {
+				logrus.Fatal(warnErr)
+			}
+		}
+		publishTag = *distTagFlag
+	}
+	logrus.Infof("Publishing under the %s dist-tag", publishTag)
+
+	opts := publishOptions{tag: publishTag, otp: otpSource, dryRun: *dryRun, pm: *pm, availabilityTimeout: *availabilityTimeout}
+
+	// Pack each workspace once, the same tarballs being published to every registry
+	tarballDir := *packDir
+	if tarballDir == "" {
+		tmpDir, err := os.MkdirTemp("", "npm-publish-")
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to create the directory of the tarballs")
+		}
+		removeTarballs := func() {
+			if err := os.RemoveAll(tmpDir); err != nil {
+				logrus.WithError(err).Warnf("unable to remove the directory of the tarballs %s", tmpDir)
+			}
+		}
+		// the deferred call is skipped when exiting through logrus.Fatal, e.g. on a pack failure
+		logrus.RegisterExitHandler(removeTarballs)
+		defer removeTarballs()
+		tarballDir = tmpDir
+	} else if err := os.MkdirAll(tarballDir, 0755); err != nil { //nolint: gosec
+		logrus.WithError(err).Fatalf("unable to create %s", tarballDir)
+	}
+	stop = report.Track("pack")
+	tarballs := make(map[string]tarball, len(workspaces))
 	for _, workspace := range workspaces {
-		logrus.Infof("Publishing workspace: %s", workspace)
-		if err := publishPackage(workspace, *dryRun); err != nil {
-			logrus.WithError(err).Errorf("failed to publish workspace: %s", workspace)
-			failures = append(failures, workspace)
+		packed, err := packWorkspace(workspace, tarballDir, *pm)
+		if err != nil {
+			logrus.WithError(err).Fatal("pack failed")
+		}
+		logrus.Infof("✓ Workspace %s packed into %s (sha1: %s)", workspace, packed.Path, packed.Shasum)
+		tarballs[workspace] = packed
+	}
+	stop()
+
+	// Publish each workspace, registry after registry. A failure on one registry doesn't prevent publishing to the next one.
+	entries, failedRegistries := publishTargets(targets, tarballs, dependencies, opts, *verifyInstall, *parallelism, report)
+
+	if failedRegistries > 0 && *rollbackFlag {
+		logrus.Warn("Deprecating the versions of the partial release...")
+		if err := rollback(entries, strings.ReplaceAll(*rollbackMessage, "{version}", expectedVersion), otpSource); err != nil {
+			logrus.WithError(err).Error("rollback failed")
+		}
+	}
+
+	if *output != "" {
+		if err := writeSummary(*output, entries); err != nil {
+			logrus.WithError(err).Errorf("unable to write the summary to %s", *output)
 		}
 	}
 
-	if len(failures) > 0 {
-		logrus.Fatalf("failed to publish %d workspace(s): %v", len(failures), failures)
+	if failedRegistries > 0 {
+		logrus.Fatalf("publish failed on %d of %d registry(ies)", failedRegistries, len(targets))
 	}
 
 	logrus.Info("All packages published successfully!")
+	report.Log()
 }
@@ -0,0 +1,201 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/config"
+	"github.com/perses/shared/scripts/testutil"
+	"github.com/perses/shared/scripts/timing"
+)
+
+func TestMain(m *testing.M) {
+	config.RegisterFlags()
+	os.Exit(m.Run())
+}
+
+// setStrict sets --strict for the duration of the test, so that the validation warnings are returned as errors.
+func setStrict(t *testing.T, strict bool) {
+	t.Helper()
+	if err := flag.Set("strict", fmt.Sprint(strict)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := flag.Set("strict", "false"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// writeWorkspaces creates count workspaces named workspace-00, workspace-01, etc., the version of each being given by version.
+func writeWorkspaces(t testing.TB, count int, version func(i int) string) []string {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	files := make(map[string]string, count)
+	workspaces := make([]string, 0, count)
+	for i := range count {
+		workspace := fmt.Sprintf("workspace-%02d", i)
+		workspaces = append(workspaces, workspace)
+		files[workspace+"/package.json"] = fmt.Sprintf(`{"name": "@perses-dev/%s", "version": "%s"}`, workspace, version(i))
+	}
+	testutil.WriteFiles(t, dir, files)
+	return workspaces
+}
+
+func TestVerifyVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		version func(i int) string
+		wantErr string
+	}{
+		{
+			name:    "all versions match",
+			version: func(int) string { return "1.0.0" },
+		},
+		{
+			name: "mismatches sorted by workspace",
+			version: func(i int) string {
+				if i%7 == 3 {
+					return "0.9.0"
+				}
+				return "1.0.0"
+			},
+			wantErr: "version mismatch in workspace(s):\n" +
+				"  workspace-03 (expected: 1.0.0, found: 0.9.0)\n" +
+				"  workspace-10 (expected: 1.0.0, found: 0.9.0)\n" +
+				"  workspace-17 (expected: 1.0.0, found: 0.9.0)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			workspaces := writeWorkspaces(t, 20, test.version)
+			// the reads complete in any order, the report must not depend on it
+			for range 10 {
+				err := verifyVersions(workspaces, "1.0.0", 8)
+				if test.wantErr == "" {
+					if err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("got error %v, expected:\n%s", err, test.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerifyVersions(b *testing.B) {
+	workspaces := writeWorkspaces(b, 50, func(int) string { return "1.0.0" })
+	for b.Loop() {
+		if err := verifyVersions(workspaces, "1.0.0", 4); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// fakePublishRegistries is a fake npm where nothing is published yet, and where publishing the tarballs listed in the file
+// rejected fails. Each publication is recorded in the file published as `<registry> <tarball>`.
+const fakePublishRegistries = `
+case "$1" in
+  view) echo "npm error code E404" >&2; exit 1 ;;
+  publish)
+    tarball=$2
+    while [ $# -gt 0 ]; do
+      if [ "$1" = "--registry" ]; then registry=$2; fi
+      shift
+    done
+    if grep -qx "$registry $tarball" rejected; then echo "npm error code E403" >&2; exit 1; fi
+    echo "$registry $tarball" >> published
+    ;;
+  *) exit 2 ;;
+esac
+`
+
+func TestPublishTargets(t *testing.T) {
+	const (
+		primary = "https://registry.npmjs.org/"
+		mirror  = "https://npm.example.com/"
+	)
+	tests := []struct {
+		name          string
+		rejected      []string
+		wantPublished []string
+		wantStatuses  []string
+		wantFailed    int
+	}{
+		{
+			name:          "published to both registries",
+			wantPublished: []string{mirror + " components.tgz", mirror + " core.tgz", primary + " components.tgz", primary + " core.tgz"},
+			wantStatuses: []string{
+				primary + " core published", primary + " components published",
+				mirror + " core published", mirror + " components published",
+			},
+		},
+		{
+			name:          "failure on one registry",
+			rejected:      []string{mirror + " core.tgz"},
+			wantPublished: []string{primary + " components.tgz", primary + " core.tgz"},
+			wantStatuses: []string{
+				primary + " core published", primary + " components published",
+				mirror + " core failed", mirror + " components failed",
+			},
+			wantFailed: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			testutil.WriteFiles(t, dir, map[string]string{
+				"core/package.json":       `{"name": "@perses-dev/core", "version": "1.0.0"}`,
+				"components/package.json": `{"name": "@perses-dev/components", "version": "1.0.0"}`,
+				"rejected":                strings.Join(test.rejected, "\n") + "\n",
+				"published":               "",
+			})
+			testutil.FakeCommand(t, "npm", fakePublishRegistries)
+
+			workspaces := []string{"core", "components"}
+			targets := []publishTarget{{registry: primary, workspaces: workspaces}, {registry: mirror, workspaces: workspaces}}
+			tarballs := map[string]tarball{"core": {Path: "core.tgz"}, "components": {Path: "components.tgz"}}
+			dependencies := map[string][]string{"components": {"core"}}
+			opts := publishOptions{tag: "latest", pm: "npm"}
+			entries, failed := publishTargets(targets, tarballs, dependencies, opts, false, 2, timing.New())
+
+			published := strings.Split(strings.TrimSpace(testutil.ReadFile(t, "published")), "\n")
+			slices.Sort(published)
+			wantPublished := slices.Sorted(slices.Values(test.wantPublished))
+			if !slices.Equal(published, wantPublished) {
+				t.Errorf("published %q, expected %q", published, wantPublished)
+			}
+			var statuses []string
+			for _, entry := range entries {
+				statuses = append(statuses, fmt.Sprintf("%s %s %s", entry.Registry, entry.Workspace, entry.Status))
+			}
+			if !slices.Equal(statuses, test.wantStatuses) {
+				t.Errorf("reported %q, expected %q", statuses, test.wantStatuses)
+			}
+			if failed != test.wantFailed {
+				t.Errorf("%d registry(ies) failed, expected %d", failed, test.wantFailed)
+			}
+		})
+	}
+}
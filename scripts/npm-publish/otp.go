@@ -0,0 +1,107 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// errOTPRejected is returned once the registry rejected the static one-time password: every other operation needing it fails
+// right away rather than being attempted with a code that has expired.
+var errOTPRejected = errors.New("the one-time password was rejected by the registry, it has likely expired: a code is only valid for about 30 seconds, use -otp-command to generate a fresh one for each operation")
+
+var otpPattern = regexp.MustCompile(`^[0-9]{6,8}$`)
+
+// otpSource provides the one-time password of an npm account protected by 2FA.
+// A TOTP code is only valid for about 30 seconds, which a release publishing several packages easily outlives. With a command
+// (e.g. a password manager CLI), a fresh code is generated for each operation, and once more when the registry rejects it.
+// A static code only suits a release completing within its validity.
+type otpSource struct {
+	static  string
+	command string
+
+	mutex    sync.Mutex
+	rejected bool
+}
+
+// newOTPSource returns the source of the one-time passwords, failing fast on a malformed static code.
+func newOTPSource(static string, command string) (*otpSource, error) {
+	if static != "" && command != "" {
+		return nil, errors.New("-otp and -otp-command are mutually exclusive")
+	}
+	if static != "" && !otpPattern.MatchString(static) {
+		return nil, fmt.Errorf("invalid one-time password %q, expected 6 to 8 digits", static)
+	}
+	return &otpSource{static: static, command: command}, nil
+}
+
+// enabled returns true if a one-time password is provided at all.
+func (s *otpSource) enabled() bool {
+	return s != nil && (s.static != "" || s.command != "")
+}
+
+// get returns the one-time password to use for the next operation, or an empty string if none is provided.
+func (s *otpSource) get() (string, error) {
+	if !s.enabled() {
+		return "", nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.command == "" {
+		if s.rejected {
+			return "", errOTPRejected
+		}
+		return s.static, nil
+	}
+	// the commands of password managers may prompt, they're run one at a time
+	data, err := exec.Command("sh", "-c", s.command).Output() //nolint: gosec
+	if err != nil {
+		return "", fmt.Errorf("unable to generate a one-time password with %q: %w", s.command, err)
+	}
+	otp := strings.TrimSpace(string(data))
+	if !otpPattern.MatchString(otp) {
+		return "", fmt.Errorf("the command %q didn't print a one-time password", s.command)
+	}
+	return otp, nil
+}
+
+// run runs the command built by newCommand with a one-time password, and returns its combined output.
+// When the registry rejects the code (EOTP), the command is run once more with a fresh code if they're generated, otherwise the
+// static code is marked as rejected.
+func (s *otpSource) run(newCommand func(otp string) *exec.Cmd) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		otp, err := s.get()
+		if err != nil {
+			return nil, err
+		}
+		output, err := newCommand(otp).CombinedOutput()
+		if err == nil || !strings.Contains(string(output), "EOTP") || !s.enabled() {
+			return output, err
+		}
+		if s.command == "" {
+			s.mutex.Lock()
+			s.rejected = true
+			s.mutex.Unlock()
+			return output, errOTPRejected
+		}
+		if attempt == 2 {
+			return output, fmt.Errorf("the one-time password generated by %q was rejected by the registry: %w", s.command, err)
+		}
+	}
+}
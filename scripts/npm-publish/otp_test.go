@@ -0,0 +1,143 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+// fakeOTPRegistry is a fake npm accepting the one-time passwords listed in the file accepted, and rejecting the others with EOTP.
+// Each invocation is recorded in the file calls.
+const fakeOTPRegistry = `
+echo "$*" >> calls
+while [ $# -gt 0 ]; do
+  if [ "$1" = "--otp" ] && grep -qx "$2" accepted; then exit 0; fi
+  shift
+done
+echo "npm error code EOTP" >&2
+exit 1
+`
+
+// otpCommand prints 100001, then 100002, etc.
+const otpCommand = `n=$(cat generated 2>/dev/null || echo 0); n=$((n+1)); echo $n > generated; echo 10000$n`
+
+func TestOTPSourceRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		static    string
+		command   string
+		accepted  string
+		runs      int
+		wantCalls int
+		wantErr   bool
+		rejected  bool
+	}{
+		{
+			name:      "static code accepted",
+			static:    "123456",
+			accepted:  "123456",
+			runs:      2,
+			wantCalls: 2,
+		},
+		{
+			name:      "expired static code fails fast",
+			static:    "123456",
+			accepted:  "654321",
+			runs:      3,
+			wantCalls: 1,
+			wantErr:   true,
+			rejected:  true,
+		},
+		{
+			name:      "generated code accepted",
+			command:   otpCommand,
+			accepted:  "100001\n100002",
+			runs:      2,
+			wantCalls: 2,
+		},
+		{
+			name:      "expired generated code renewed",
+			command:   otpCommand,
+			accepted:  "100002",
+			runs:      1,
+			wantCalls: 2,
+		},
+		{
+			name:      "renewed generated code rejected",
+			command:   otpCommand,
+			accepted:  "999999",
+			runs:      1,
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			testutil.WriteFiles(t, dir, map[string]string{"accepted": test.accepted + "\n"})
+			testutil.FakeCommand(t, "npm", fakeOTPRegistry)
+
+			source, err := newOTPSource(test.static, test.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range test.runs {
+				_, err = source.run(func(otp string) *exec.Cmd {
+					return exec.Command("npm", "publish", "--otp", otp)
+				})
+				if err != nil {
+					break
+				}
+			}
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if errors.Is(err, errOTPRejected) != test.rejected {
+				t.Errorf("got error %v, expected the code to be rejected: %t", err, test.rejected)
+			}
+			if got := len(strings.Split(strings.TrimSpace(testutil.ReadFile(t, "calls")), "\n")); got != test.wantCalls {
+				t.Errorf("npm ran %d time(s), expected %d", got, test.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNewOTPSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		static  string
+		command string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "static", static: "123456"},
+		{name: "command", command: "op item get npm --otp"},
+		{name: "malformed static", static: "12345a", wantErr: true},
+		{name: "static and command", static: "123456", command: "op item get npm --otp", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newOTPSource(test.static, test.command)
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
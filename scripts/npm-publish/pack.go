@@ -0,0 +1,82 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha1" //nolint: gosec
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+)
+
+// tarball is a packed workspace. The same tarball is published to every registry.
+type tarball struct {
+	Path      string
+	Shasum    string
+	Integrity string
+}
+
+// tarballName returns the name npm gives to the tarball of a package, e.g. perses-dev-components-0.1.0.tgz for @perses-dev/components.
+func tarballName(name string, version string) string {
+	return fmt.Sprintf("%s-%s.tgz", strings.ReplaceAll(strings.TrimPrefix(name, "@"), "/", "-"), version)
+}
+
+// packWorkspace packs the workspace into dir with the package manager pm, with the files it would publish (its `files` field
+// selects the dist directory). Packing with the package manager that publishes matters: pnpm and yarn rewrite the workspace: ranges of the manifest.
+func packWorkspace(workspace string, dir string, pm string) (tarball, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return tarball{}, err
+	}
+	pck, err := npm.GetPackage(workspace)
+	if err != nil {
+		return tarball{}, fmt.Errorf("unable to read package.json for workspace %s: %w", workspace, err)
+	}
+	path := filepath.Join(absDir, tarballName(pck.Name, pck.Version))
+	cmd, err := packCommand(path, pm)
+	if err != nil {
+		return tarball{}, err
+	}
+	cmd.Dir = workspace
+	if output, cmdErr := cmd.CombinedOutput(); cmdErr != nil {
+		return tarball{}, fmt.Errorf("unable to pack workspace %s: %w\n%s", workspace, cmdErr, strings.TrimSpace(string(output)))
+	}
+	return hashTarball(path)
+}
+
+// hashTarball computes the checksums the registries report for the tarball: its SHA-1 and its SHA-512 subresource integrity.
+func hashTarball(path string) (tarball, error) {
+	f, err := os.Open(path) //nolint: gosec
+	if err != nil {
+		return tarball{}, fmt.Errorf("unable to read the tarball: %w", err)
+	}
+	defer f.Close()
+	sha1Hash := sha1.New() //nolint: gosec
+	sha512Hash := sha512.New()
+	if _, err := io.Copy(io.MultiWriter(sha1Hash, sha512Hash), f); err != nil {
+		return tarball{}, fmt.Errorf("unable to read the tarball %s: %w", path, err)
+	}
+	return tarball{
+		Path:      path,
+		Shasum:    hex.EncodeToString(sha1Hash.Sum(nil)),
+		Integrity: "sha512-" + base64.StdEncoding.EncodeToString(sha512Hash.Sum(nil)),
+	}, nil
+}
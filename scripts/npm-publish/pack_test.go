@@ -0,0 +1,78 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestPackWorkspace(t *testing.T) {
+	if _, err := exec.LookPath("npm"); err != nil {
+		t.Skip("npm is not installed")
+	}
+	root := t.TempDir()
+	testutil.WriteFiles(t, root, map[string]string{
+		"core/package.json":  `{"name": "@perses-dev/core", "version": "1.2.3", "files": ["dist"]}`,
+		"core/src/index.ts":  "export {};\n",
+		"core/dist/index.js": "export {};\n",
+	})
+	workspace := filepath.Join(root, "core")
+	if err := os.Mkdir(filepath.Join(root, "tarballs"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	packed, err := packWorkspace(workspace, filepath.Join(root, "tarballs"), "npm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "tarballs", "perses-dev-core-1.2.3.tgz"); packed.Path != want {
+		t.Errorf("packed into %s, expected %s", packed.Path, want)
+	}
+
+	// the checksums must be the ones npm reports, which are the ones the registry serves
+	cmd := exec.Command("npm", "pack", "--dry-run", "--json")
+	cmd.Dir = workspace
+	data, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []struct {
+		Shasum    string `json:"shasum"`
+		Integrity string `json:"integrity"`
+		Files     []struct {
+			Path string `json:"path"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &reported); err != nil || len(reported) != 1 {
+		t.Fatalf("unexpected output of npm pack: %s", data)
+	}
+	if packed.Shasum != reported[0].Shasum || packed.Integrity != reported[0].Integrity {
+		t.Errorf("got checksums %s / %s, npm reports %s / %s", packed.Shasum, packed.Integrity, reported[0].Shasum, reported[0].Integrity)
+	}
+	// the files field of the workspace selects the dist directory, the sources aren't packed
+	var files []string
+	for _, f := range reported[0].Files {
+		files = append(files, f.Path)
+	}
+	if want := []string{"dist/index.js", "package.json"}; !slices.Equal(files, want) {
+		t.Errorf("packed files %q, expected %q", files, want)
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/config"
+	"github.com/perses/shared/scripts/semver"
+	"github.com/sirupsen/logrus"
+)
+
+// manifest holds the package.json fields used by the publish checks that npm.Package doesn't expose.
+type manifest struct {
+	Name             string            `json:"name"`
+	Version          string            `json:"version"`
+	Private          bool              `json:"private"`
+	Types            string            `json:"types"`
+	Files            []string          `json:"files"`
+	PeerDependencies map[string]string `json:"peerDependencies"`
+	PublishConfig    struct {
+		Registry string `json:"registry"`
+	} `json:"publishConfig"`
+}
+
+func readManifest(workspacePath string) (manifest, error) {
+	data, err := os.ReadFile(filepath.Join(workspacePath, "package.json")) //nolint: gosec
+	if err != nil {
+		return manifest{}, err
+	}
+	m := manifest{}
+	if unmarshalErr := json.Unmarshal(data, &m); unmarshalErr != nil {
+		return manifest{}, fmt.Errorf("unable to parse package.json of %s: %w", workspacePath, unmarshalErr)
+	}
+	return m, nil
+}
+
+// verifyRootPrivate checks the root package.json of the monorepo is private, so that it can never be published by mistake.
+func verifyRootPrivate() error {
+	m, err := readManifest(".")
+	if err != nil {
+		return err
+	}
+	if !m.Private {
+		return fmt.Errorf("the root package %s must be marked with \"private\": true", m.Name)
+	}
+	return nil
+}
+
+// broadFilesPatterns are `files` entries that include the whole package directory, sources included.
+var broadFilesPatterns = []string{".", "./", "*", "**", "**/*"}
+
+// lintFiles warns about public packages relying on npm defaults to select the published files, or using an overly broad `files` field.
+func lintFiles(workspaces []string) error {
+	var issues []string
+	for _, workspace := range workspaces {
+		m, err := readManifest(workspace)
+		if err != nil {
+			return err
+		}
+		if m.Private {
+			continue
+		}
+		if len(m.Files) == 0 {
+			if warnErr := config.Warnf("workspace %s has no files field, npm will publish the whole directory", workspace); warnErr != nil {
+				issues = append(issues, warnErr.Error())
+			}
+			continue
+		}
+		for _, pattern := range m.Files {
+			if slices.Contains(broadFilesPatterns, pattern) {
+				if warnErr := config.Warnf("workspace %s has an overly broad files entry %q", workspace, pattern); warnErr != nil {
+					issues = append(issues, warnErr.Error())
+				}
+			}
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("files field check failed:\n  %s", strings.Join(issues, "\n  "))
+	}
+	return nil
+}
+
+// verifyDistContent fails when the dist directory of a workspace contains a node_modules directory or a nested package.json,
+// which a misconfigured build can bundle into the published package.
+// A nested package.json only declaring the module type (e.g. `{"type": "commonjs"}` in dist/cjs) is expected.
+func verifyDistContent(workspaces []string) error {
+	var issues []string
+	for _, workspace := range workspaces {
+		distPath := filepath.Join(workspace, "dist")
+		if _, err := os.Stat(distPath); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.WalkDir(distPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == "node_modules" {
+				issues = append(issues, fmt.Sprintf("%s: node_modules directory", path))
+				return filepath.SkipDir
+			}
+			if d.IsDir() || d.Name() != "package.json" || filepath.Dir(path) == distPath {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			fields := make(map[string]json.RawMessage)
+			if err := json.Unmarshal(data, &fields); err != nil || len(fields) != 1 || fields["type"] == nil {
+				issues = append(issues, fmt.Sprintf("%s: nested package.json", path))
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to scan %s: %w", distPath, err)
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("unexpected content in dist:\n  %s", strings.Join(issues, "\n  "))
+	}
+	return nil
+}
+
+// lintFileSizes warns about the files of the dist directories bigger than maxSize (in MB),
+// like an un-minified bundle or a binary copied by mistake.
+func lintFileSizes(workspaces []string, maxSize int64) error {
+	var issues []string
+	for _, workspace := range workspaces {
+		distPath := filepath.Join(workspace, "dist")
+		if _, err := os.Stat(distPath); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.WalkDir(distPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() > maxSize*1024*1024 {
+				if warnErr := config.Warnf("%s is %.1f MB, more than the %d MB limit", path, float64(info.Size())/(1024*1024), maxSize); warnErr != nil {
+					issues = append(issues, warnErr.Error())
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unable to scan %s: %w", distPath, err)
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("file size check failed:\n  %s", strings.Join(issues, "\n  "))
+	}
+	return nil
+}
+
+// verifyPeerDependencies checks that the peer dependencies on other workspaces of the repository are satisfiable by consumers:
+// the peer must be public, and either published along with the package depending on it, or already available on the registry
+// the package is published to in a version matching the required range.
+func verifyPeerDependencies(allWorkspaces []string, targets []publishTarget) error {
+	manifests := make(map[string]manifest, len(allWorkspaces))
+	workspaceOf := make(map[string]string, len(allWorkspaces))
+	for _, workspace := range allWorkspaces {
+		m, err := readManifest(workspace)
+		if err != nil {
+			return err
+		}
+		manifests[workspace] = m
+		workspaceOf[m.Name] = workspace
+	}
+
+	published := make(map[string]bool)
+	for _, target := range targets {
+		for _, workspace := range target.workspaces {
+			published[workspace] = true
+		}
+	}
+
+	var violations []string
+	for _, target := range targets {
+		for _, workspace := range target.workspaces {
+			peers := make([]string, 0, len(manifests[workspace].PeerDependencies))
+			for peer := range manifests[workspace].PeerDependencies {
+				peers = append(peers, peer)
+			}
+			slices.Sort(peers)
+			for _, peer := range peers {
+				peerWorkspace, internal := workspaceOf[peer]
+				if !internal {
+					continue
+				}
+				if manifests[peerWorkspace].Private {
+					violations = append(violations, fmt.Sprintf("%s: peer dependency %s is private", workspace, peer))
+					continue
+				}
+				if published[peerWorkspace] {
+					continue
+				}
+				versionRange := resolveWorkspaceRange(manifests[workspace].PeerDependencies[peer], manifests[peerWorkspace].Version)
+				available, err := isPublished(peer, versionRange, target.registry)
+				if err != nil {
+					return err
+				}
+				if !available {
+					violations = append(violations, fmt.Sprintf("%s: peer dependency %s@%s is not published along with it, and not available on %s", workspace, peer, versionRange, target.registry))
+				}
+			}
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("unsatisfiable peer dependencies:\n  %s", strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+// resolveWorkspaceRange returns the range a workspace: range is published as, the way pnpm and yarn rewrite it, version being
+// the version of the workspace it refers to. Other ranges are returned unchanged.
+func resolveWorkspaceRange(versionRange string, version string) string {
+	r, found := strings.CutPrefix(versionRange, "workspace:")
+	if !found {
+		return versionRange
+	}
+	switch r {
+	case "*":
+		return version
+	case "^", "~":
+		return r + version
+	default:
+		return r
+	}
+}
+
+// getDefaultRegistry returns the registry npm publishes to when none is given explicitly.
+func getDefaultRegistry() (string, error) {
+	data, err := exec.Command("npm", "config", "get", "registry").Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get the npm registry: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// verifyAuthentication checks that the package manager pm is authenticated to every given registry.
+// The output of `whoami` on failure is deliberately not reported, so that no credential can leak in the logs.
+func verifyAuthentication(registries []string, pm string) error {
+	for _, registry := range registries {
+		cmd, err := whoamiCommand(registry, pm)
+		if err != nil {
+			return err
+		}
+		data, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("not authenticated to %s", registry)
+		}
+		logrus.Infof("✓ Authenticated to %s as %s", registry, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// verifyPublishAccess checks, scope by scope, that the current token is allowed to publish every workspace to its registry.
+// Granular tokens can be restricted to some packages, which otherwise only surfaces in the middle of the publication.
+// Packages that were never published aren't listed by npm and can't be verified.
+func verifyPublishAccess(targets []publishTarget) error {
+	var denied []string
+	for _, target := range targets {
+		registry := target.registry
+		scopes := make(map[string][]string)
+		var scopeNames []string
+		for _, workspace := range target.workspaces {
+			pck, err := npm.GetPackage(workspace)
+			if err != nil {
+				return fmt.Errorf("unable to read package.json for workspace %s: %w", workspace, err)
+			}
+			scope, _, found := strings.Cut(pck.Name, "/")
+			if !found {
+				scope = pck.Name
+			}
+			if _, ok := scopes[scope]; !ok {
+				scopeNames = append(scopeNames, scope)
+			}
+			scopes[scope] = append(scopes[scope], pck.Name)
+		}
+		for _, scope := range scopeNames {
+			// the output only contains package names and permissions, the token is never part of it
+			data, err := exec.Command("npm", "access", "list", "packages", scope, "--json", "--registry", registry).Output()
+			if err != nil {
+				if warnErr := config.Warnf("unable to verify the publish access to %s on %s", scope, registry); warnErr != nil {
+					return warnErr
+				}
+				continue
+			}
+			permissions := make(map[string]string)
+			if err := json.Unmarshal(data, &permissions); err != nil {
+				return fmt.Errorf("unable to parse the access of %s on %s: %w", scope, registry, err)
+			}
+			for _, name := range scopes[scope] {
+				if permission, ok := permissions[name]; ok && permission != "read-write" {
+					denied = append(denied, fmt.Sprintf("%s on %s (%s)", name, registry, permission))
+				}
+			}
+		}
+	}
+	if len(denied) > 0 {
+		return fmt.Errorf("the current token can't publish:\n  %s", strings.Join(denied, "\n  "))
+	}
+	logrus.Info("✓ Publish access verified")
+	return nil
+}
+
+// checkTypes type-checks the declared `types` entry of each workspace on its own, with the TypeScript compiler
+// installed in the repository. It catches declarations referencing types that were not emitted into dist.
+func checkTypes(workspaces []string) error {
+	var failures []string
+	for _, workspace := range workspaces {
+		m, err := readManifest(workspace)
+		if err != nil {
+			return err
+		}
+		if m.Types == "" {
+			if warnErr := config.Warnf("workspace %s doesn't declare any types entry, skipping type-check", workspace); warnErr != nil {
+				failures = append(failures, warnErr.Error())
+			}
+			continue
+		}
+		typesPath := filepath.Join(workspace, m.Types)
+		if _, err := os.Stat(typesPath); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: types entry %s not found", workspace, typesPath))
+			continue
+		}
+		cmd := exec.Command("npx", "--no-install", "tsc", "--noEmit", "--pretty", "false",
+			"--module", "esnext", "--moduleResolution", "bundler", "--target", "es2022", "--jsx", "react-jsx", "--esModuleInterop",
+			typesPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s\n%s", workspace, typesPath, strings.TrimSpace(string(output))))
+			continue
+		}
+		logrus.Infof("✓ Types entry %s type-checks", typesPath)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("types entry check failed for %d workspace(s):\n  %s", len(failures), strings.Join(failures, "\n  "))
+	}
+	return nil
+}
+
+// getPublishedVersion returns the version tagged latest on the registry, or an empty string if the package was never published.
+func getPublishedVersion(name string, registry string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("npm", "view", name, "version", "--registry", registry)
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "E404") {
+			return "", nil
+		}
+		return "", fmt.Errorf("unable to get the published version of %s from %s: %w", name, registry, err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// isPublished returns true if the given version of the package is already on the registry.
+// npm doesn't print anything for an unknown version of a published package, and fails with E404 for an unknown package.
+func isPublished(name string, version string, registry string) (bool, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("npm", "view", fmt.Sprintf("%s@%s", name, version), "version", "--registry", registry)
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "E404") {
+			return false, nil
+		}
+		return false, fmt.Errorf("unable to check if %s@%s is published on %s: %w", name, version, registry, err)
+	}
+	return strings.TrimSpace(string(data)) != "", nil
+}
+
+// verifyNotLowerThanPublished checks that the version about to be published is not lower than the latest one already published
+// to the registry of each workspace.
+func verifyNotLowerThanPublished(targets []publishTarget, version string) error {
+	toPublish, err := semver.Parse(version)
+	if err != nil {
+		return err
+	}
+	var lowers []string
+	for _, target := range targets {
+		registry := target.registry
+		for _, workspace := range target.workspaces {
+			pck, err := npm.GetPackage(workspace)
+			if err != nil {
+				return fmt.Errorf("unable to read package.json for workspace %s: %w", workspace, err)
+			}
+			published, err := getPublishedVersion(pck.Name, registry)
+			if err != nil {
+				return err
+			}
+			if published == "" {
+				logrus.Infof("%s has never been published to %s", pck.Name, registry)
+				continue
+			}
+			latest, err := semver.Parse(published)
+			if err != nil {
+				return err
+			}
+			if semver.Compare(toPublish, latest) < 0 {
+				lowers = append(lowers, fmt.Sprintf("%s on %s (publishing: %s, latest: %s)", pck.Name, registry, version, published))
+			}
+		}
+	}
+	if len(lowers) > 0 {
+		return fmt.Errorf("version lower than the published one for package(s):\n  %s", strings.Join(lowers, "\n  "))
+	}
+	return nil
+}
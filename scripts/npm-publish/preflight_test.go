@@ -0,0 +1,551 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+// fakeRegistry is a fake npm for `npm view <name>@<range> version`, serving the given name@range specs.
+const fakeRegistry = `
+if [ "$1" = "view" ]; then
+  case "$2" in
+    %s) echo 1.0.0; exit 0 ;;
+  esac
+  echo "npm error code E404" >&2
+  exit 1
+fi
+exit 2
+`
+
+func TestVerifyPeerDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		peerRange string
+		private   bool
+		served    string
+		published []string
+		wantErr   string
+	}{
+		{
+			name:      "peer published along",
+			peerRange: "1.0.0",
+			published: []string{"core", "components"},
+		},
+		{
+			name:      "filtered out peer available on the registry",
+			peerRange: "1.0.0",
+			served:    "@perses-dev/core@1.0.0",
+			published: []string{"components"},
+		},
+		{
+			name:      "filtered out peer missing from the registry",
+			peerRange: "1.0.0",
+			served:    "@perses-dev/core@0.9.0",
+			published: []string{"components"},
+			wantErr:   "components: peer dependency @perses-dev/core@1.0.0 is not published along with it",
+		},
+		{
+			name:      "filtered out peer with a workspace range",
+			peerRange: "workspace:^",
+			served:    "@perses-dev/core@^1.0.0",
+			published: []string{"components"},
+		},
+		{
+			name:      "private peer",
+			peerRange: "1.0.0",
+			private:   true,
+			served:    "@perses-dev/core@1.0.0",
+			published: []string{"components"},
+			wantErr:   "components: peer dependency @perses-dev/core is private",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"core/package.json":       fmt.Sprintf(`{"name": "@perses-dev/core", "version": "1.0.0", "private": %t}`, test.private),
+				"components/package.json": `{"name": "@perses-dev/components", "version": "1.0.0", "peerDependencies": {"@perses-dev/core": "` + test.peerRange + `", "react": "^18.0.0"}}`,
+			})
+			served := test.served
+			if served == "" {
+				served = "none"
+			}
+			testutil.FakeCommand(t, "npm", strings.Replace(fakeRegistry, "%s", served, 1))
+
+			err := verifyPeerDependencies([]string{"core", "components"}, []publishTarget{{registry: "https://registry.example.com/", workspaces: test.published}})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveWorkspaceRange(t *testing.T) {
+	tests := []struct {
+		versionRange string
+		want         string
+	}{
+		{versionRange: "^1.0.0", want: "^1.0.0"},
+		{versionRange: "workspace:*", want: "1.2.3"},
+		{versionRange: "workspace:^", want: "^1.2.3"},
+		{versionRange: "workspace:~", want: "~1.2.3"},
+		{versionRange: "workspace:^1.0.0", want: "^1.0.0"},
+	}
+	for _, test := range tests {
+		if got := resolveWorkspaceRange(test.versionRange, "1.2.3"); got != test.want {
+			t.Errorf("resolveWorkspaceRange(%q) = %q, expected %q", test.versionRange, got, test.want)
+		}
+	}
+}
+
+func TestVerifyAuthentication(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{
+			name:   "authenticated",
+			script: `[ "$1" = "whoami" ] && echo perses-bot`,
+		},
+		{
+			name:    "unauthenticated",
+			script:  `echo "npm error code ENEEDAUTH" >&2; exit 1`,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.FakeCommand(t, "npm", test.script+"\n")
+			err := verifyAuthentication([]string{"https://registry.example.com/"}, "npm")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if err != nil && strings.Contains(err.Error(), "ENEEDAUTH") {
+				t.Errorf("the output of npm leaks in the error %q", err)
+			}
+		})
+	}
+}
+
+// fakeTSC is a fake npx running a fake tsc, rejecting the declaration files referencing a missing module.
+const fakeTSC = `
+[ "$1 $2 $3" = "--no-install tsc --noEmit" ] || exit 1
+for last; do :; done
+if grep -q "./missing" "$last"; then
+  echo "$last(1,25): error TS2307: Cannot find module './missing' or its corresponding type declarations."
+  exit 2
+fi
+`
+
+func TestCheckTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "dist type-checks",
+			files: map[string]string{
+				"core/package.json":    `{"name": "@perses-dev/core", "types": "dist/index.d.ts"}`,
+				"core/dist/index.d.ts": "export * from './model';\n",
+				"core/dist/model.d.ts": "export type Model = string;\n",
+			},
+		},
+		{
+			name: "dist referencing a type not emitted",
+			files: map[string]string{
+				"core/package.json":    `{"name": "@perses-dev/core", "types": "dist/index.d.ts"}`,
+				"core/dist/index.d.ts": "export * from './missing';\n",
+			},
+			wantErr: "error TS2307: Cannot find module './missing'",
+		},
+		{
+			name: "types entry not found",
+			files: map[string]string{
+				"core/package.json": `{"name": "@perses-dev/core", "types": "dist/index.d.ts"}`,
+			},
+			wantErr: "core: types entry core/dist/index.d.ts not found",
+		},
+		{
+			name: "no types entry",
+			files: map[string]string{
+				"core/package.json": `{"name": "@perses-dev/core"}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", test.files)
+			testutil.FakeCommand(t, "npx", fakeTSC)
+			err := checkTypes([]string{"core"})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// fakeLatest is a fake npm for `npm view <name> version`, serving $NPM_LATEST as the latest version of every package.
+const fakeLatest = `
+[ "$1" = "view" ] || exit 2
+if [ -z "$NPM_LATEST" ]; then
+  echo "npm error code E404" >&2
+  exit 1
+fi
+echo "$NPM_LATEST"
+`
+
+func TestVerifyNotLowerThanPublished(t *testing.T) {
+	tests := []struct {
+		name    string
+		latest  string
+		version string
+		wantErr bool
+	}{
+		{
+			name:    "higher version",
+			latest:  "1.0.0",
+			version: "1.1.0",
+		},
+		{
+			name:    "prerelease of the next version",
+			latest:  "1.0.0",
+			version: "1.1.0-rc.0",
+		},
+		{
+			name:    "never published",
+			version: "0.1.0",
+		},
+		{
+			name:    "lower version",
+			latest:  "1.0.0",
+			version: "0.9.0",
+			wantErr: true,
+		},
+		{
+			name:    "prerelease of the published version",
+			latest:  "1.0.0",
+			version: "1.0.0-rc.0",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			workspaces := writeWorkspaces(t, 2, func(int) string { return test.version })
+			t.Setenv("NPM_LATEST", test.latest)
+			testutil.FakeCommand(t, "npm", fakeLatest)
+
+			err := verifyNotLowerThanPublished([]publishTarget{{registry: "https://registry.example.com/", workspaces: workspaces}}, test.version)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "@perses-dev/workspace-01 on https://registry.example.com/ (publishing: "+test.version+", latest: 1.0.0)") {
+				t.Errorf("every lower package should be reported, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLintFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		strict   bool
+		wantErr  string
+	}{
+		{
+			name:     "files field",
+			manifest: `{"name": "@perses-dev/core", "files": ["dist"]}`,
+			strict:   true,
+		},
+		{
+			name:     "private package without files field",
+			manifest: `{"name": "@perses-dev/internal", "private": true}`,
+			strict:   true,
+		},
+		{
+			name:     "no files field only warned",
+			manifest: `{"name": "@perses-dev/core"}`,
+		},
+		{
+			name:     "no files field under --strict",
+			manifest: `{"name": "@perses-dev/core"}`,
+			strict:   true,
+			wantErr:  "workspace core has no files field",
+		},
+		{
+			name:     "overly broad files entry under --strict",
+			manifest: `{"name": "@perses-dev/core", "files": ["dist", "**/*"]}`,
+			strict:   true,
+			wantErr:  `workspace core has an overly broad files entry "**/*"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{"core/package.json": test.manifest})
+			setStrict(t, test.strict)
+
+			err := lintFiles([]string{"core"})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyRootPrivate(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  bool
+	}{
+		{
+			name:     "private root",
+			manifest: `{"name": "perses-shared", "private": true, "workspaces": ["core"]}`,
+		},
+		{
+			name:     "root without private field",
+			manifest: `{"name": "perses-shared", "workspaces": ["core"]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "root explicitly public",
+			manifest: `{"name": "perses-shared", "private": false, "workspaces": ["core"]}`,
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{"package.json": test.manifest})
+			if err := verifyRootPrivate(); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// fakeAccess is a fake npm for `npm access list packages <scope> --json`, printing $NPM_ACCESS_<scope without @ nor dash>
+// as the permissions of the token on the packages of the scope, and failing if it's not set.
+const fakeAccess = `
+[ "$1 $2 $3" = "access list packages" ] || exit 2
+scope=$(echo "$4" | tr -d '@-')
+eval "access=\$NPM_ACCESS_$scope"
+[ -n "$access" ] || exit 1
+echo "$access"
+`
+
+func TestVerifyPublishAccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		access  map[string]string
+		strict  bool
+		wantErr string
+	}{
+		{
+			name: "read-write on every scope",
+			access: map[string]string{
+				"persesdev":     `{"@perses-dev/core": "read-write", "@perses-dev/components": "read-write"}`,
+				"persesplugins": `{"@perses-plugins/timeseries": "read-write"}`,
+			},
+		},
+		{
+			name: "package never published",
+			access: map[string]string{
+				"persesdev":     `{"@perses-dev/core": "read-write"}`,
+				"persesplugins": `{"@perses-plugins/timeseries": "read-write"}`,
+			},
+		},
+		{
+			name: "read-only on one scope",
+			access: map[string]string{
+				"persesdev":     `{"@perses-dev/core": "read-write", "@perses-dev/components": "read-write"}`,
+				"persesplugins": `{"@perses-plugins/timeseries": "read-only"}`,
+			},
+			wantErr: "the current token can't publish:\n  @perses-plugins/timeseries on https://registry.example.com/ (read-only)",
+		},
+		{
+			name: "scope access not listed",
+			access: map[string]string{
+				"persesdev": `{"@perses-dev/core": "read-write", "@perses-dev/components": "read-write"}`,
+			},
+		},
+		{
+			name: "scope access not listed under --strict",
+			access: map[string]string{
+				"persesdev": `{"@perses-dev/core": "read-write", "@perses-dev/components": "read-write"}`,
+			},
+			strict:  true,
+			wantErr: "unable to verify the publish access to @perses-plugins",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"core/package.json":       `{"name": "@perses-dev/core", "version": "1.0.0"}`,
+				"components/package.json": `{"name": "@perses-dev/components", "version": "1.0.0"}`,
+				"timeseries/package.json": `{"name": "@perses-plugins/timeseries", "version": "1.0.0"}`,
+			})
+			for _, scope := range []string{"persesdev", "persesplugins"} {
+				t.Setenv("NPM_ACCESS_"+scope, test.access[scope])
+			}
+			testutil.FakeCommand(t, "npm", fakeAccess)
+			setStrict(t, test.strict)
+
+			err := verifyPublishAccess([]publishTarget{{registry: "https://registry.example.com/", workspaces: []string{"core", "components", "timeseries"}}})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyDistContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "clean dist",
+			files: map[string]string{
+				"core/dist/index.js":         "export {};\n",
+				"core/dist/cjs/index.js":     "module.exports = {};\n",
+				"core/dist/cjs/package.json": `{"type": "commonjs"}`,
+			},
+		},
+		{
+			name:  "no dist",
+			files: map[string]string{"core/src/index.ts": "export {};\n"},
+		},
+		{
+			name: "dist with node_modules",
+			files: map[string]string{
+				"core/dist/index.js":                         "export {};\n",
+				"core/dist/node_modules/lodash/package.json": `{"name": "lodash"}`,
+			},
+			wantErr: "core/dist/node_modules: node_modules directory",
+		},
+		{
+			name: "dist with a nested package",
+			files: map[string]string{
+				"core/dist/index.js":            "export {};\n",
+				"core/dist/vendor/package.json": `{"name": "vendor", "type": "module"}`,
+			},
+			wantErr: "core/dist/vendor/package.json: nested package.json",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", test.files)
+			err := verifyDistContent([]string{"core"})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestLintFileSizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		large   bool
+		strict  bool
+		wantErr bool
+	}{
+		{
+			name:   "small files",
+			strict: true,
+		},
+		{
+			name:  "large file only warned",
+			large: true,
+		},
+		{
+			name:    "large file under --strict",
+			large:   true,
+			strict:  true,
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"core/dist/index.js":     "export {};\n",
+				"core/dist/cjs/index.js": "module.exports = {};\n",
+				"core/dist/bundle.js":    "export {};\n",
+			})
+			if test.large {
+				// a sparse file, 2 MB large without taking the space
+				if err := os.Truncate("core/dist/bundle.js", 2*1024*1024); err != nil {
+					t.Fatal(err)
+				}
+			}
+			setStrict(t, test.strict)
+
+			err := lintFileSizes([]string{"core"}, 1)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			if want := "file size check failed:\n  core/dist/bundle.js is 2.0 MB, more than the 1 MB limit"; err.Error() != want {
+				t.Errorf("got error %q, expected only the large file to be reported: %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rollback deprecates the versions on the registries where the publication of some workspaces failed, so that consumers don't
+// install a half-released set of packages. The versions already there before the run are deprecated as well, as they belong
+// to the same release. Published versions can't be removed from npm, deprecating them is the closest to a rollback.
+func rollback(entries []publishEntry, message string, otp *otpSource) error {
+	failedRegistries := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Status == statusFailed {
+			failedRegistries[entry.Registry] = true
+		}
+	}
+	var failures []string
+	for i, entry := range entries {
+		if !failedRegistries[entry.Registry] || entry.Status == statusFailed {
+			continue
+		}
+		pkg := fmt.Sprintf("%s@%s", entry.Package, entry.Version)
+		if entry.DryRun {
+			logrus.Infof("Would deprecate %s on %s (dry-run)", pkg, entry.Registry)
+			continue
+		}
+		output, err := otp.run(func(code string) *exec.Cmd {
+			args := []string{"deprecate", pkg, message, "--registry", entry.Registry}
+			if code != "" {
+				args = append(args, "--otp", code)
+			}
+			return exec.Command("npm", args...)
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s on %s: %v\n%s", pkg, entry.Registry, err, strings.TrimSpace(string(output))))
+			continue
+		}
+		entries[i].Deprecated = true
+		logrus.Infof("Deprecated %s on %s", pkg, entry.Registry)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to deprecate %d version(s), deprecate them manually:\n  %s", len(failures), strings.Join(failures, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+const (
+	statusPublished = "published"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+)
+
+// publishEntry is the outcome of the publication of a workspace to a registry, in the machine-readable summary of the run.
+type publishEntry struct {
+	Package   string `json:"package"`
+	Version   string `json:"version"`
+	Workspace string `json:"workspace"`
+	Registry  string `json:"registry"`
+	Tag       string `json:"tag"`
+	// Shasum and Integrity identify the published tarball, like in the dist field of the registry metadata.
+	Shasum    string `json:"shasum"`
+	Integrity string `json:"integrity"`
+	// Status is published, skipped when the version was already on the registry, or failed.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	DryRun bool   `json:"dryRun,omitempty"`
+	// Deprecated is true when the version was deprecated by -rollback, after the failure of another workspace.
+	Deprecated bool `json:"deprecated,omitempty"`
+}
+
+// writeSummary writes the outcome of each publication to path as JSON.
+func writeSummary(path string, entries []publishEntry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644) //nolint: gosec
+}
@@ -0,0 +1,85 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// installAttempts bounds how many times the install is tried while the registry propagates the new version.
+const installAttempts = 6
+
+// installDelay is the time between two install attempts, shortened by the tests.
+var installDelay = 10 * time.Second
+
+// verifyInstallable installs the published package in a temporary directory to make sure it can be resolved from the registry.
+func verifyInstallable(name string, version string, registry string) error {
+	tmpDir, err := os.MkdirTemp("", "npm-publish-verify-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(tmpDir); removeErr != nil {
+			logrus.WithError(removeErr).Warnf("unable to remove the temporary directory %s", tmpDir)
+		}
+	}()
+
+	pkg := fmt.Sprintf("%s@%s", name, version)
+	var output []byte
+	for attempt := 1; attempt <= installAttempts; attempt++ {
+		cmd := exec.Command("npm", "install", pkg, "--registry", registry, "--no-save", "--ignore-scripts", "--no-audit", "--no-fund")
+		cmd.Dir = tmpDir
+		output, err = cmd.CombinedOutput()
+		if err == nil {
+			logrus.Infof("✓ %s is installable from %s", pkg, registry)
+			return nil
+		}
+		if attempt < installAttempts {
+			logrus.Infof("%s not installable from %s yet (attempt %d/%d), retrying in %s", pkg, registry, attempt, installAttempts, installDelay)
+			time.Sleep(installDelay)
+		}
+	}
+	return fmt.Errorf("%s is not installable from %s after %d attempts: %w\n%s", pkg, registry, installAttempts, err, strings.TrimSpace(string(output)))
+}
+
+// availabilityDelay is the time between two checks of the availability of a published version.
+const availabilityDelay = 5 * time.Second
+
+// waitAvailable polls the registry until it reports the published version, failing after timeout.
+// A registry can acknowledge a publication before serving the version, which then isn't installable for a while.
+func waitAvailable(name string, version string, registry string, timeout time.Duration) error {
+	pkg := fmt.Sprintf("%s@%s", name, version)
+	deadline := time.Now().Add(timeout)
+	for {
+		available, err := isPublished(name, version, registry)
+		if err != nil {
+			logrus.WithError(err).Warnf("unable to check the availability of %s on %s", pkg, registry)
+		}
+		if available {
+			logrus.Infof("✓ %s is available on %s", pkg, registry)
+			return nil
+		}
+		if time.Now().Add(availabilityDelay).After(deadline) {
+			return fmt.Errorf("%s is still not available on %s %s after its publication", pkg, registry, timeout)
+		}
+		logrus.Debugf("%s not available on %s yet, checking again in %s", pkg, registry, availabilityDelay)
+		time.Sleep(availabilityDelay)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+// fakeInstall is a fake npm failing `npm install` until it's been attempted $NPM_FAILURES times, as while the registry
+// propagates a new version. The attempts are counted in $NPM_ATTEMPTS, the arguments of the last one kept in $NPM_ARGS.
+const fakeInstall = `
+[ "$1" = "install" ] || exit 2
+n=$(cat "$NPM_ATTEMPTS" 2>/dev/null || echo 0); n=$((n+1)); echo $n > "$NPM_ATTEMPTS"
+echo "$@" > "$NPM_ARGS"
+if [ $n -le $NPM_FAILURES ]; then
+  echo "npm error code ETARGET" >&2
+  exit 1
+fi
+`
+
+func TestVerifyInstallable(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{
+			name:         "installable right away",
+			wantAttempts: 1,
+		},
+		{
+			name:         "installable once propagated",
+			failures:     2,
+			wantAttempts: 3,
+		},
+		{
+			name:         "never installable",
+			failures:     installAttempts,
+			wantAttempts: installAttempts,
+			wantErr:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			attempts := filepath.Join(dir, "attempts")
+			args := filepath.Join(dir, "args")
+			t.Setenv("NPM_FAILURES", strconv.Itoa(test.failures))
+			t.Setenv("NPM_ATTEMPTS", attempts)
+			t.Setenv("NPM_ARGS", args)
+			testutil.FakeCommand(t, "npm", fakeInstall)
+			defer func(delay time.Duration) { installDelay = delay }(installDelay)
+			installDelay = time.Millisecond
+
+			err := verifyInstallable("@perses-dev/core", "1.2.0", "https://registry.example.com")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if got := strings.TrimSpace(testutil.ReadFile(t, attempts)); got != strconv.Itoa(test.wantAttempts) {
+				t.Errorf("install attempted %s times, expected %d", got, test.wantAttempts)
+			}
+			if got := testutil.ReadFile(t, args); !strings.HasPrefix(got, "install @perses-dev/core@1.2.0 --registry https://registry.example.com ") {
+				t.Errorf("unexpected install command: npm %s", got)
+			}
+		})
+	}
+}
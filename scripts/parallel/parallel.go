@@ -0,0 +1,134 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Map calls fn on every item with at most limit calls running concurrently.
+// The results are returned in the order of the items, whatever the order of completion.
+func Map[T any, R any](limit int, items []T, fn func(T) R) []R {
+	if limit < 1 {
+		limit = 1
+	}
+	results := make([]R, len(items))
+	semaphore := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			results[i] = fn(item)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ErrDependencyFailed is the result of the items not processed by Graph because an item they depend on failed.
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// Graph calls fn on every item with at most limit calls running concurrently, an item being only processed once the items
+// it depends on, as returned by deps, are successfully processed. The dependencies that are not part of items are ignored.
+// The items depending on a failed item, directly or not, are not processed and get an error wrapping ErrDependencyFailed.
+// The results are returned in the order of the items; among the items ready to be processed, the first ones are started first.
+func Graph[T comparable](limit int, items []T, deps func(T) []T, fn func(T) error) []error {
+	if limit < 1 {
+		limit = 1
+	}
+	index := make(map[T]int, len(items))
+	for i, item := range items {
+		index[item] = i
+	}
+	// pending counts the dependencies of each item not processed yet
+	pending := make([]int, len(items))
+	dependents := make([][]int, len(items))
+	for i, item := range items {
+		for _, dep := range deps(item) {
+			if j, ok := index[dep]; ok && j != i {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	results := make([]error, len(items))
+	settled := make([]bool, len(items))
+	var ready []int
+	for i := range items {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	var settle func(i int, err error)
+	settle = func(i int, err error) {
+		settled[i] = true
+		results[i] = err
+		for _, d := range dependents[i] {
+			if settled[d] {
+				continue
+			}
+			if err != nil {
+				settle(d, fmt.Errorf("%w: %v", ErrDependencyFailed, items[i]))
+				continue
+			}
+			if pending[d]--; pending[d] == 0 {
+				// keep the ready items in the order of the items
+				j, _ := slices.BinarySearch(ready, d)
+				ready = slices.Insert(ready, j, d)
+			}
+		}
+	}
+
+	type completion struct {
+		i   int
+		err error
+	}
+	done := make(chan completion)
+	running := 0
+	for {
+		for len(ready) > 0 && running < limit {
+			i := ready[0]
+			ready = ready[1:]
+			if settled[i] {
+				continue
+			}
+			running++
+			go func() {
+				done <- completion{i: i, err: fn(items[i])}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+		c := <-done
+		running--
+		settle(c.i, c.err)
+	}
+	// the items left are part of a dependency cycle
+	for i := range items {
+		if !settled[i] {
+			results[i] = fmt.Errorf("%v is part of a dependency cycle", items[i])
+		}
+	}
+	return results
+}
@@ -0,0 +1,188 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parallel
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMap(t *testing.T) {
+	items := []int{5, 4, 3, 2, 1}
+	var running, maxRunning atomic.Int32
+	results := Map(2, items, func(item int) string {
+		n := running.Add(1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		// the first items complete last
+		time.Sleep(time.Duration(item) * time.Millisecond)
+		running.Add(-1)
+		return fmt.Sprintf("item %d", item)
+	})
+	want := []string{"item 5", "item 4", "item 3", "item 2", "item 1"}
+	if !slices.Equal(results, want) {
+		t.Errorf("got %q, expected %q", results, want)
+	}
+	if maxRunning.Load() > 2 {
+		t.Errorf("%d calls ran concurrently, expected at most 2", maxRunning.Load())
+	}
+}
+
+func TestGraph(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name string
+		// items are processed in the order of their name, which maps an item to its dependencies
+		items     []string
+		deps      map[string][]string
+		failing   []string
+		wantOrder []string
+		wantErrs  map[string]error
+	}{
+		{
+			name:      "independent items in the order given",
+			items:     []string{"c", "a", "b"},
+			wantOrder: []string{"c", "a", "b"},
+		},
+		{
+			name:      "dependencies first",
+			items:     []string{"dashboards", "components", "core", "plugin-system"},
+			deps:      map[string][]string{"dashboards": {"components", "plugin-system"}, "components": {"core"}, "plugin-system": {"components", "core"}},
+			wantOrder: []string{"core", "components", "plugin-system", "dashboards"},
+		},
+		{
+			name:      "dependencies outside of the items ignored",
+			items:     []string{"explore", "core"},
+			deps:      map[string][]string{"explore": {"dashboards", "core"}},
+			wantOrder: []string{"core", "explore"},
+		},
+		{
+			name:      "failed item stops its dependents",
+			items:     []string{"core", "components", "plugin-system", "dashboards", "client"},
+			deps:      map[string][]string{"components": {"core"}, "plugin-system": {"components"}, "dashboards": {"plugin-system"}},
+			failing:   []string{"components"},
+			wantOrder: []string{"core", "components", "client"},
+			wantErrs: map[string]error{
+				"components":    errFailed,
+				"plugin-system": ErrDependencyFailed,
+				"dashboards":    ErrDependencyFailed,
+			},
+		},
+		{
+			name:      "dependency cycle",
+			items:     []string{"a", "b", "c"},
+			deps:      map[string][]string{"a": {"b"}, "b": {"a"}},
+			wantOrder: []string{"c"},
+			wantErrs:  map[string]error{"a": nil, "b": nil},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var order []string
+			results := Graph(1, test.items, func(item string) []string {
+				return test.deps[item]
+			}, func(item string) error {
+				mutex.Lock()
+				order = append(order, item)
+				mutex.Unlock()
+				if slices.Contains(test.failing, item) {
+					return errFailed
+				}
+				return nil
+			})
+			if !slices.Equal(order, test.wantOrder) {
+				t.Errorf("processed %q, expected %q", order, test.wantOrder)
+			}
+			for i, item := range test.items {
+				wantErr, failed := test.wantErrs[item]
+				switch {
+				case !failed && results[i] != nil:
+					t.Errorf("%s: unexpected error %v", item, results[i])
+				case failed && results[i] == nil:
+					t.Errorf("%s: expected an error", item)
+				case failed && wantErr != nil && !errors.Is(results[i], wantErr):
+					t.Errorf("%s: got error %v, expected %v", item, results[i], wantErr)
+				}
+			}
+		})
+	}
+}
+
+// TestGraphConcurrent checks that, whatever the concurrency, an item only starts once its dependencies completed.
+func TestGraphConcurrent(t *testing.T) {
+	items, deps := layeredGraph(10, 8)
+	var mutex sync.Mutex
+	completed := make(map[int]bool)
+	results := Graph(4, items, deps, func(item int) error {
+		mutex.Lock()
+		for _, dep := range deps(item) {
+			if !completed[dep] {
+				mutex.Unlock()
+				return fmt.Errorf("%d started before its dependency %d completed", item, dep)
+			}
+		}
+		mutex.Unlock()
+		time.Sleep(100 * time.Microsecond)
+		mutex.Lock()
+		completed[item] = true
+		mutex.Unlock()
+		return nil
+	})
+	if err := errors.Join(results...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// layeredGraph returns layers*width items, each item depending on every item of the previous layer.
+func layeredGraph(layers int, width int) ([]int, func(int) []int) {
+	items := make([]int, 0, layers*width)
+	for i := range layers * width {
+		items = append(items, i)
+	}
+	return items, func(item int) []int {
+		layer := item / width
+		if layer == 0 {
+			return nil
+		}
+		var deps []int
+		for i := (layer - 1) * width; i < layer*width; i++ {
+			deps = append(deps, i)
+		}
+		return deps
+	}
+}
+
+func BenchmarkGraph(b *testing.B) {
+	items, deps := layeredGraph(20, 10)
+	for b.Loop() {
+		Graph(4, items, deps, func(int) error { return nil })
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	items := make([]int, 200)
+	for b.Loop() {
+		Map(4, items, func(item int) int { return item })
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pkgjson reads and writes package.json files while preserving every field and their order,
+// unlike npm.Package which only knows about a few fields.
+package pkgjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Object is a JSON object keeping the order of its fields.
+type Object struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// Parse decodes a JSON object, keeping the values of its fields as they are.
+func Parse(data []byte) (*Object, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	obj := &Object{values: make(map[string]json.RawMessage)}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a field name, got %v", token)
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid value for field %q: %w", key, err)
+		}
+		obj.Set(key, value)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Keys returns the field names in order.
+func (o *Object) Keys() []string {
+	return slices.Clone(o.keys)
+}
+
+// Get returns the raw value of the given field.
+func (o *Object) Get(key string) (json.RawMessage, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// Set replaces the value of the given field, or appends the field if it doesn't exist yet.
+func (o *Object) Set(key string, value json.RawMessage) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Reorder moves the fields listed in order first, in that order. The other fields keep their relative order after them.
+func (o *Object) Reorder(order []string) {
+	keys := make([]string, 0, len(o.keys))
+	for _, key := range order {
+		if _, ok := o.values[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range o.keys {
+		if !slices.Contains(order, key) {
+			keys = append(keys, key)
+		}
+	}
+	o.keys = keys
+}
+
+// Marshal encodes the object with a two-space indentation and a trailing newline, as npm does.
+func (o *Object) Marshal() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteString("{")
+	for i, key := range o.keys {
+		if i > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n  ")
+		name, err := marshalString(key)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(name)
+		buffer.WriteString(": ")
+		if err := json.Indent(&buffer, o.values[key], "  ", "  "); err != nil {
+			return nil, fmt.Errorf("invalid value for field %q: %w", key, err)
+		}
+	}
+	if len(o.keys) > 0 {
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString("}\n")
+	return buffer.Bytes(), nil
+}
+
+func marshalString(s string) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(s); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// Read reads the package.json file of the given directory.
+func Read(dirPath string) (*Object, error) {
+	path := filepath.Join(dirPath, "package.json")
+	data, err := os.ReadFile(path) //nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	obj, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return obj, nil
+}
+
+// Write writes the package.json file of the given directory.
+func Write(dirPath string, obj *Object) error {
+	data, err := obj.Marshal()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dirPath, "package.json"), data, 0644) // nolint: gosec
+}
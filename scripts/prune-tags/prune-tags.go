@@ -0,0 +1,85 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/perses/shared/scripts/gh"
+	"github.com/perses/shared/scripts/git"
+	"github.com/sirupsen/logrus"
+)
+
+// pruneCandidates returns the local v* tags that have no GitHub release.
+func pruneCandidates() ([]string, error) {
+	tags, err := git.ListTags("v*")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the local tags: %w", err)
+	}
+	candidates, err := gh.MissingReleases(tags)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the GitHub releases: %w", err)
+	}
+	return candidates, nil
+}
+
+// This script removes the local tags that no longer have a GitHub release.
+// Stale tags are picked up by `git describe` and would make the changelog generated by the release script inaccurate.
+//
+// Prerequisites for running this script:
+// - Install the GitHub CLI (gh): https://github.com/cli/cli#installation
+// - Use it to log in to GitHub: `gh auth login`
+//
+// Usage:
+//
+// By default, the script only reports the tags that would be deleted:
+//
+//	go run ./scripts/prune-tags
+//
+// To actually delete them:
+//
+//	go run ./scripts/prune-tags --delete
+func main() {
+	deleteTags := flag.Bool("delete", false, "Delete the local tags without a GitHub release instead of only reporting them")
+	flag.Parse()
+
+	if err := gh.CheckVersion(); err != nil {
+		logrus.Fatal(err)
+	}
+
+	candidates, err := pruneCandidates()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if len(candidates) == 0 {
+		logrus.Info("No local tag to prune")
+		return
+	}
+
+	for _, tag := range candidates {
+		if !*deleteTags {
+			logrus.Infof("Tag %s has no GitHub release and would be deleted", tag)
+			continue
+		}
+		if err := git.DeleteTag(tag); err != nil {
+			logrus.WithError(err).Fatalf("unable to prune the tag %s", tag)
+		}
+		logrus.Infof("✓ Deleted tag %s", tag)
+	}
+
+	if !*deleteTags {
+		logrus.Infof("%d tag(s) can be pruned, run again with --delete to remove them", len(candidates))
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestPruneCandidates(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		releases []string
+		want     []string
+	}{
+		{
+			name:     "every tag released",
+			tags:     []string{"v0.52.0", "v0.53.0"},
+			releases: []string{"v0.53.0", "v0.52.0"},
+		},
+		{
+			name:     "tags without release",
+			tags:     []string{"v0.51.0", "v0.52.0", "v0.53.0-rc.0", "v0.53.0"},
+			releases: []string{"v0.53.0", "v0.51.0"},
+			want:     []string{"v0.52.0", "v0.53.0-rc.0"},
+		},
+		{
+			name: "no release at all",
+			tags: []string{"v0.1.0"},
+			want: []string{"v0.1.0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.FakeCommand(t, "git", `[ "$1 $2 $3" = "tag --list v*" ] || exit 1
+printf '%s\n' `+strings.Join(test.tags, " ")+`
+`)
+			testutil.FakeCommand(t, "gh", `[ "$1 $2" = "release list" ] || exit 1
+printf '%s\n' `+strings.Join(test.releases, " ")+`
+`)
+			got, err := pruneCandidates()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/diff"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPattern matches the version of a static shields.io npm badge, e.g. https://img.shields.io/badge/npm-v0.54.0--beta.10-blue
+const defaultPattern = `img\.shields\.io/badge/npm-v([0-9A-Za-z.]+(?:--[0-9A-Za-z.]+)*)-`
+
+// updateBadges replaces the first capture group of every match of pattern in content by version.
+// In shields.io badge URLs, the dashes of the version are escaped as shields expects them.
+func updateBadges(content []byte, pattern *regexp.Regexp, version string) []byte {
+	var result bytes.Buffer
+	last := 0
+	for _, match := range pattern.FindAllSubmatchIndex(content, -1) {
+		if len(match) < 4 || match[2] < 0 {
+			continue
+		}
+		replacement := version
+		if bytes.Contains(content[match[0]:match[1]], []byte("img.shields.io/badge/")) {
+			replacement = strings.ReplaceAll(version, "-", "--")
+		}
+		result.Write(content[last:match[2]])
+		result.WriteString(replacement)
+		last = match[3]
+	}
+	result.Write(content[last:])
+	return result.Bytes()
+}
+
+// This script updates the version badge in the README of each workspace to the published version.
+//
+// Usage:
+//
+//	go run ./scripts/readme-badges
+//
+// To preview the changes, or to use another badge format (the first capture group of the regex being the version):
+//
+//	go run ./scripts/readme-badges --dry-run --pattern 'badge/version-([^-]+)-'
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Print the changes as a unified diff instead of writing them")
+	patternFlag := flag.String("pattern", defaultPattern, "Regex matching the badges, its first capture group being the version to replace")
+	versionFlag := flag.String("version", "", "Version to set in the badges, defaults to the version of the root package.json")
+	flag.Parse()
+
+	pattern, err := regexp.Compile(*patternFlag)
+	if err != nil {
+		logrus.WithError(err).Fatal("invalid badge pattern")
+	}
+	if pattern.NumSubexp() < 1 {
+		logrus.Fatal("the badge pattern must have a capture group matching the version")
+	}
+	version := *versionFlag
+	if version == "" {
+		version = npm.MustGetVersion(".")
+	}
+
+	for _, workspace := range npm.MustGetWorkspaces(".") {
+		readmePath := filepath.Join(workspace, "README.md")
+		original, err := os.ReadFile(readmePath) //nolint: gosec
+		if errors.Is(err, os.ErrNotExist) {
+			logrus.Debugf("No README in workspace %s", workspace)
+			continue
+		}
+		if err != nil {
+			logrus.WithError(err).Fatalf("unable to read the file %s", readmePath)
+		}
+		updated := updateBadges(original, pattern, version)
+		if bytes.Equal(original, updated) {
+			continue
+		}
+		if *dryRun {
+			fmt.Print(diff.Unified(readmePath, original, updated))
+			continue
+		}
+		if err := os.WriteFile(readmePath, updated, 0644); err != nil { // nolint: gosec
+			logrus.WithError(err).Fatalf("unable to write the file %s", readmePath)
+		}
+		logrus.Infof("✓ Updated the version badge of %s to %s", readmePath, version)
+	}
+}
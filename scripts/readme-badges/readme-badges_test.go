@@ -0,0 +1,85 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestUpdateBadges(t *testing.T) {
+	const readme = `# @perses-dev/core
+
+![npm](https://img.shields.io/badge/npm-v0.53.0-blue)
+
+Install it with npm install @perses-dev/core@v0.53.0, see the [changelog](https://img.shields.io/badge/changelog-v0.53.0-green).
+`
+	tests := []struct {
+		name    string
+		pattern string
+		version string
+		want    string
+	}{
+		{
+			name:    "stable version",
+			pattern: defaultPattern,
+			version: "0.54.0",
+			want: `# @perses-dev/core
+
+![npm](https://img.shields.io/badge/npm-v0.54.0-blue)
+
+Install it with npm install @perses-dev/core@v0.53.0, see the [changelog](https://img.shields.io/badge/changelog-v0.53.0-green).
+`,
+		},
+		{
+			name:    "prerelease dashes escaped",
+			pattern: defaultPattern,
+			version: "0.54.0-beta.10",
+			want: `# @perses-dev/core
+
+![npm](https://img.shields.io/badge/npm-v0.54.0--beta.10-blue)
+
+Install it with npm install @perses-dev/core@v0.53.0, see the [changelog](https://img.shields.io/badge/changelog-v0.53.0-green).
+`,
+		},
+		{
+			name:    "custom pattern",
+			pattern: `core@v([0-9.]+)`,
+			version: "0.54.0-beta.10",
+			want: `# @perses-dev/core
+
+![npm](https://img.shields.io/badge/npm-v0.53.0-blue)
+
+Install it with npm install @perses-dev/core@v0.54.0-beta.10, see the [changelog](https://img.shields.io/badge/changelog-v0.53.0-green).
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := updateBadges([]byte(readme), regexp.MustCompile(test.pattern), test.version)
+			if string(got) != test.want {
+				t.Errorf("unexpected README:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestUpdateBadgesPrerelease(t *testing.T) {
+	// a badge already at a prerelease is matched as a whole, its escaped dashes included
+	const readme = "![npm](https://img.shields.io/badge/npm-v0.54.0--beta.10-blue)\n"
+	got := updateBadges([]byte(readme), regexp.MustCompile(defaultPattern), "0.54.0")
+	if want := "![npm](https://img.shields.io/badge/npm-v0.54.0-blue)\n"; string(got) != want {
+		t.Errorf("got %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/sirupsen/logrus"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// notification is the JSON payload posted to the webhook once a release is created.
+type notification struct {
+	Version  string   `json:"version"`
+	URL      string   `json:"url"`
+	Summary  string   `json:"summary"`
+	Packages []string `json:"packages"`
+}
+
+// notify posts the release to the webhook. A failure is only reported as a warning,
+// as the release itself is already done at this point.
+func notify(webhook string, releaseName string, summary string, workspaces []string) {
+	url, err := exec.Command("gh", "release", "view", releaseName, "--json", "url", "--jq", ".url").Output()
+	if err != nil {
+		logrus.WithError(err).Warnf("unable to get the URL of release %s", releaseName)
+	}
+	payload := notification{
+		Version: releaseName,
+		URL:     strings.TrimSpace(string(url)),
+		Summary: summary,
+	}
+	for _, workspace := range workspaces {
+		pck, err := npm.GetPackage(workspace)
+		if err != nil {
+			logrus.WithError(err).Warnf("unable to read package.json for workspace %s", workspace)
+			continue
+		}
+		payload.Packages = append(payload.Packages, fmt.Sprintf("%s@%s", pck.Name, pck.Version))
+	}
+
+	if err := postNotification(webhook, payload); err != nil {
+		logrus.WithError(err).Warn("unable to notify the release")
+		return
+	}
+	logrus.Info("✓ Release notified")
+}
+
+func postNotification(webhook string, payload notification) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: notifyTimeout}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(data)) //nolint: noctx
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+	"github.com/sirupsen/logrus"
+)
+
+func TestNotify(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantLogs string
+	}{
+		{
+			name:     "notified",
+			status:   http.StatusNoContent,
+			wantLogs: "Release notified",
+		},
+		{
+			name:     "webhook failure is only a warning",
+			status:   http.StatusInternalServerError,
+			wantLogs: "unable to notify the release",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			testutil.WriteFiles(t, dir, map[string]string{
+				"core/package.json":       `{"name": "@perses-dev/core", "version": "0.2.0"}`,
+				"components/package.json": `{"name": "@perses-dev/components", "version": "0.2.0"}`,
+			})
+			testutil.FakeCommand(t, "gh", `echo "https://github.com/perses/shared/releases/tag/$3"`)
+
+			var contentType string
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contentType = r.Header.Get("Content-Type")
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			var logs bytes.Buffer
+			logrus.SetOutput(&logs)
+			defer logrus.SetOutput(os.Stderr)
+
+			notify(server.URL, "v0.2.0", "## Features\n- something", []string{"core", "components"})
+
+			if contentType != "application/json" {
+				t.Errorf("posted as %q, expected application/json", contentType)
+			}
+			var payload map[string]any
+			if err := json.Unmarshal(body, &payload); err != nil {
+				t.Fatalf("invalid payload %s: %v", body, err)
+			}
+			want := map[string]any{
+				"version":  "v0.2.0",
+				"url":      "https://github.com/perses/shared/releases/tag/v0.2.0",
+				"summary":  "## Features\n- something",
+				"packages": []any{"@perses-dev/core@0.2.0", "@perses-dev/components@0.2.0"},
+			}
+			wantData, _ := json.Marshal(want)
+			gotData, _ := json.Marshal(payload)
+			if string(gotData) != string(wantData) {
+				t.Errorf("got payload %s, expected %s", gotData, wantData)
+			}
+			if !strings.Contains(logs.String(), test.wantLogs) {
+				t.Errorf("logs %q don't contain %q", logs.String(), test.wantLogs)
+			}
+		})
+	}
+}
@@ -14,61 +14,216 @@
 package main
 
 import (
-	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"os/exec"
+	"strings"
 
-	"github.com/perses/perses/scripts/pkg/changelog"
 	"github.com/perses/perses/scripts/pkg/command"
 	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/perses/shared/scripts/config"
+	"github.com/perses/shared/scripts/gh"
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/lock"
+	"github.com/perses/shared/scripts/notes"
+	"github.com/perses/shared/scripts/semver"
+	"github.com/perses/shared/scripts/tag"
+	"github.com/perses/shared/scripts/timing"
 	"github.com/sirupsen/logrus"
 )
 
-func release() {
-	// Get version from root package.json and format it.
-	releaseName := fmt.Sprintf("v%s", npm.MustGetVersion("."))
+var (
+	changelogTransforms notes.SubjectTransforms
+	changelogFilter     notes.TypeFilter
+)
+
+// latestFlag returns the value of the gh `--latest` flag for the release, or an empty string to let GitHub decide.
+// In auto mode, a prerelease never becomes the latest release.
+func latestFlag(releaseName string, makeLatest string) string {
+	if makeLatest != "auto" {
+		return makeLatest
+	}
+	version, err := semver.Parse(releaseName)
+	if err != nil {
+		logrus.WithError(err).Fatalf("unable to parse the version of release %s", releaseName)
+	}
+	if version.IsPrerelease() {
+		return "false"
+	}
+	return ""
+}
+
+// release creates the GitHub release releaseName.
+// When existingTag is true, the release is created from the tag already pushed, otherwise gh creates the tag.
+// It returns the changelog of the release, or false when the release already exists.
+func release(releaseName string, existingTag bool, verifySignature bool, allowUnsigned bool, allowMajor bool, makeLatest string) (string, bool) {
+	args := []string{"release", "create", releaseName, "-t", releaseName}
+	if latest := latestFlag(releaseName, makeLatest); latest != "" {
+		args = append(args, "--latest="+latest)
+	}
+	target := "HEAD"
 
-	// ensure the tag does not already exist
-	if execErr := command.Run("git", "rev-parse", "--verify", releaseName); execErr == nil {
+	if existingTag {
+		if !git.RefExists(releaseName) {
+			logrus.Fatalf("tag %s not found, push the tag before creating the release", releaseName)
+		}
+		if verifySignature {
+			verifyTagSignature(releaseName, allowUnsigned)
+		}
+		if err := verifyTagVersion(releaseName); err != nil {
+			logrus.Fatal(err)
+		}
+		if execErr := command.Run("gh", "release", "view", releaseName); execErr == nil {
+			logrus.Infof("release %s already exists", releaseName)
+			return "", false
+		}
+		// prevent gh from creating the tag if it's not found on the remote
+		args = append(args, "--verify-tag")
+		target = releaseName
+	} else if git.RefExists(releaseName) {
+		// ensure the tag does not already exist
 		logrus.Infof("release %s already exists", releaseName)
-		return
+		return "", false
 	}
 
+	if err := verifyMajor(releaseName, target, existingTag, allowMajor); err != nil {
+		logrus.Fatal(err)
+	}
 	logrus.Infof("Creating release %s", releaseName)
 
 	// create the GitHub release
-	if execErr := command.Run("gh", "release", "create", releaseName, "-t", releaseName, "-n", generateChangelog()); execErr != nil {
+	changelog := generateChangelog(target, existingTag)
+	args = append(args, "-n", changelog)
+	if execErr := command.Run("gh", args...); execErr != nil {
 		logrus.WithError(execErr).Fatalf("unable to create the release %s", releaseName)
 	}
 
 	logrus.Infof("✓ Successfully created release %s", releaseName)
+	return changelog, true
 }
 
-func getPreviousTag() string {
-	data, err := exec.Command("git", "describe", "--tags", "--abbrev=0", "--match", "v*").Output()
+// listMissingReleases reports the tags that exist locally but have no GitHub release.
+func listMissingReleases() {
+	tags, err := git.ListTags("v*")
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			if exitError.ExitCode() == 128 {
-				return ""
-			}
+		logrus.WithError(err).Fatal("unable to get the local tags")
+	}
+	missing, err := gh.MissingReleases(tags)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to get the GitHub releases")
+	}
+	if len(missing) == 0 {
+		logrus.Info("✓ Every tag has a GitHub release")
+		return
+	}
+	for _, tagName := range missing {
+		logrus.Warnf("tag %s has no GitHub release, run again with --tag %s to create it", tagName, tagName)
+	}
+}
+
+func verifyTagSignature(tagName string, allowUnsigned bool) {
+	err := git.VerifyTag(tagName)
+	if err == nil {
+		logrus.Infof("✓ Signature of tag %s verified", tagName)
+		return
+	}
+	if errors.Is(err, git.ErrTagNotSigned) {
+		if allowUnsigned {
+			logrus.Warnf("tag %s is not signed, proceeding as --allow-unsigned is set", tagName)
+			return
 		}
+		logrus.WithError(err).Fatalf("tag %s has no signature, sign it with `git tag -s` or use --allow-unsigned", tagName)
+	}
+	logrus.WithError(err).Fatalf("refusing to release from tag %s", tagName)
+}
 
-		logrus.Fatal(err)
+// verifyTagVersion checks that the version declared by the `Version:` trailer of the tag annotation, if any,
+// matches the version of the root package.json at that tag, to catch tags created on stale content.
+func verifyTagVersion(tagName string) error {
+	declared, err := git.TagTrailer(tagName, "Version")
+	if err != nil {
+		return fmt.Errorf("unable to read the annotation of tag %s: %w", tagName, err)
+	}
+	if declared == "" {
+		logrus.Debugf("tag %s doesn't declare a version, skipping the version check", tagName)
+		return nil
+	}
+	data, err := git.ShowFile(tagName, "package.json")
+	if err != nil {
+		return fmt.Errorf("unable to read the package.json of tag %s: %w", tagName, err)
 	}
-	return string(bytes.ReplaceAll(data, []byte("\n"), []byte("")))
+	var pck npm.Package
+	if err := json.Unmarshal(data, &pck); err != nil {
+		return fmt.Errorf("unable to parse the package.json of tag %s: %w", tagName, err)
+	}
+	if strings.TrimPrefix(declared, "v") != pck.Version {
+		return fmt.Errorf("tag %s declares version %s but its package.json is at version %s", tagName, declared, pck.Version)
+	}
+	logrus.Infof("✓ Version %s declared by tag %s matches package.json", declared, tagName)
+	return nil
 }
 
-func generateChangelog() string {
-	previousTag := getPreviousTag()
+// verifyMajor refuses to create a release that increments the major version of the previous tag, unless allowMajor is set.
+// A new major tells the users the release contains breaking changes, it must not be cut by mistake.
+func verifyMajor(releaseName string, target string, isTag bool, allowMajor bool) error {
+	from := target
+	if isTag {
+		from = target + "^"
+	}
+	previousTag, err := git.PreviousTag(from)
+	if err != nil {
+		return fmt.Errorf("unable to get the previous tag: %w", err)
+	}
+	if previousTag == "" {
+		return nil
+	}
+	previous, err := semver.Parse(previousTag)
+	if err != nil {
+		return fmt.Errorf("unable to parse the previous tag %s: %w", previousTag, err)
+	}
+	current, err := semver.Parse(releaseName)
+	if err != nil {
+		return fmt.Errorf("unable to parse the version of release %s: %w", releaseName, err)
+	}
+	if current.Major <= previous.Major {
+		return nil
+	}
+	if !allowMajor {
+		return fmt.Errorf("release %s is a new major version compared to %s, telling the users it contains breaking changes: "+
+			"use --allow-major to confirm it's intended", releaseName, previousTag)
+	}
+	logrus.Warnf("release %s is a new major version compared to %s, proceeding as --allow-major is set", releaseName, previousTag)
+	return nil
+}
+
+// generateChangelog generates the changelog of the commits reachable from target since the previous tag.
+// When target is itself a tag, the previous tag is searched from its parent.
+func generateChangelog(target string, isTag bool) string {
+	from := target
+	if isTag {
+		from = target + "^"
+	}
+	previousTag, err := git.PreviousTag(from)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to get the previous tag")
+	}
 	if previousTag == "" {
 		logrus.Infof("no previous tag found for libraries, skipping changelog generation")
 		return "First release"
 	}
 	logrus.Infof("previous tag for libraries is %s", previousTag)
-	entries := changelog.GetGitLogs(previousTag)
-
-	return changelog.New(entries).GenerateChangelog()
+	logs, err := git.Log(previousTag, target)
+	if err != nil {
+		logrus.WithError(err).Fatal("unable to get the git logs")
+	}
+	entries := changelogTransforms.Apply(logs)
+	if uncategorized := notes.Uncategorized(entries); len(uncategorized) > 0 {
+		if err := config.Warnf("%d commit(s) without catalog entry are excluded from the changelog:\n  %s", len(uncategorized), strings.Join(uncategorized, "\n  ")); err != nil {
+			logrus.WithError(err).Fatal("changelog validation failed")
+		}
+	}
+	return notes.Markdown(entries, changelogFilter)
 }
 
 // This script generates Github release(s).
@@ -83,13 +238,71 @@ func generateChangelog() string {
 //
 //	go run ./scripts/release
 //
+// To create the release from a signed tag that has already been pushed, and refuse to proceed if its signature doesn't verify:
+//
+//	go run ./scripts/release --signed-tag
+//
+// To list the tags that have no GitHub release (e.g. because a previous run failed after tagging):
+//
+//	go run ./scripts/release --list-missing
+//
+// To create the missing release of an existing tag, with the changelog of that tag's range:
+//
+//	go run ./scripts/release --tag v1.2.3
+//
+// To create the release of a backported patch without making it the latest release:
+//
+//	go run ./scripts/release --tag v1.1.4 --make-latest false
+//
+// Commit subjects can be rewritten before being added to the changelog, for example to link ticket IDs.
+// Transforms are applied in the order they are given:
+//
+//	go run ./scripts/release --changelog-transform '\[(PROJ-\d+)\]=>[$1](https://tracker.example.com/$1)'
+//
+// To notify a webhook (e.g. a chat integration) once the release is created:
+//
+//	go run ./scripts/release --notify-webhook https://hooks.example.com/releases
+//
 // NB: this script doesn't handle the plugin archive creation, a CI task is responsible for this.
 func main() {
+	signedTag := flag.Bool("signed-tag", false, "Create the release from an existing tag, verifying its GPG signature")
+	allowUnsigned := flag.Bool("allow-unsigned", false, "With --signed-tag, accept a tag that has no signature")
+	allowMajor := flag.Bool("allow-major", false, "Allow creating a release that increments the major version of the previous tag")
+	makeLatest := flag.String("make-latest", "auto", "Whether the release becomes the latest GitHub release: true, false, or auto to let GitHub decide except for prereleases that never become latest")
+	notifyWebhook := flag.String("notify-webhook", "", "URL to POST a JSON notification to once the release is created. A notification failure doesn't fail the release")
+	listMissing := flag.Bool("list-missing", false, "List the tags that don't have a GitHub release")
+	tagFlag := tag.Flag()
+	config.RegisterFlags()
+	lockTimeout := lock.Flag()
+	flag.Var(&changelogTransforms, "changelog-transform", "Regex-replace applied to each commit subject in the changelog, in the format <regex>=><replacement>. Can be repeated")
+	changelogFilter.RegisterFlags()
 	flag.Parse()
+
+	if err := changelogFilter.Validate(); err != nil {
+		logrus.Fatal(err)
+	}
+	if *makeLatest != "true" && *makeLatest != "false" && *makeLatest != "auto" {
+		logrus.Fatalf("invalid --make-latest value %q, expected true, false or auto", *makeLatest)
+	}
+
+	// Prevent concurrent release runs from racing on tags and registries
+	lock.MustAcquire(*lockTimeout)
+	defer lock.Release()
+
+	report := timing.New()
+	report.LogOnExit()
+	defer report.Log()
+
+	if err := gh.CheckVersion(); err != nil {
+		logrus.Fatal(err)
+	}
+
 	// get all tags locally
-	if err := exec.Command("git", "fetch", "--tags").Run(); err != nil {
-		logrus.WithError(err).Fatal("unable to fetch the tags")
+	stop := report.Track("fetch tags")
+	if err := git.FetchTags(); err != nil {
+		logrus.Fatal(err)
 	}
+	stop()
 
 	// Verify all workspaces exist and have the same version
 	workspaces := npm.MustGetWorkspaces(".")
@@ -99,6 +312,26 @@ func main() {
 
 	logrus.Infof("Found %d workspace(s) in monorepo", len(workspaces))
 
+	if *listMissing {
+		defer report.Track("list missing releases")()
+		listMissingReleases()
+		return
+	}
+
+	if *tagFlag != "" {
+		// validate the tag format
+		tag.Parse(tagFlag)
+		defer report.Track("release " + *tagFlag)()
+		if changelog, created := release(*tagFlag, true, *signedTag, *allowUnsigned, *allowMajor, *makeLatest); created && *notifyWebhook != "" {
+			notify(*notifyWebhook, *tagFlag, changelog, workspaces)
+		}
+		return
+	}
+
 	// Create a single release for the monorepo (all packages share the same version)
-	release()
+	releaseName := fmt.Sprintf("v%s", npm.MustGetVersion("."))
+	defer report.Track("release " + releaseName)()
+	if changelog, created := release(releaseName, *signedTag, *signedTag, *allowUnsigned, *allowMajor, *makeLatest); created && *notifyWebhook != "" {
+		notify(*notifyWebhook, releaseName, changelog, workspaces)
+	}
 }
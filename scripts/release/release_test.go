@@ -0,0 +1,282 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/gh"
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+// fakeReleases stands for gh with the releases listed in $GH_RELEASES: `release view` succeeds for those only, and the
+// arguments of `release create` are appended to $GH_LOG.
+const fakeReleases = `case "$1 $2" in
+"release list") for r in $GH_RELEASES; do echo "$r"; done ;;
+"release view") for r in $GH_RELEASES; do [ "$r" = "$3" ] && exit 0; done; exit 1 ;;
+"release create") echo "$@" >> "$GH_LOG" ;;
+*) exit 1 ;;
+esac
+`
+
+// releaseRepo creates a repository with the tags v0.1.0 and v0.2.0, and a gh knowing the given releases.
+// It returns the file logging the releases created.
+func releaseRepo(t *testing.T, releases ...string) string {
+	t.Helper()
+	dir := testutil.GitRepo(t)
+	testutil.Commit(t, "[FEATURE] first")
+	testutil.Git(t, "tag", "--annotate", "--message", "v0.1.0", "v0.1.0")
+	testutil.Commit(t, "[BUGFIX] second")
+	testutil.Git(t, "tag", "--annotate", "--message", "v0.2.0", "v0.2.0")
+	testutil.Commit(t, "[BUGFIX] unreleased")
+
+	logFile := filepath.Join(dir, "gh.log")
+	t.Setenv("GH_RELEASES", strings.Join(releases, " "))
+	t.Setenv("GH_LOG", logFile)
+	testutil.FakeCommand(t, "gh", fakeReleases)
+	return logFile
+}
+
+func TestMissingReleases(t *testing.T) {
+	tests := []struct {
+		name     string
+		releases []string
+		want     []string
+	}{
+		{
+			name:     "every tag released",
+			releases: []string{"v0.1.0", "v0.2.0"},
+		},
+		{
+			name:     "latest tag without release",
+			releases: []string{"v0.1.0"},
+			want:     []string{"v0.2.0"},
+		},
+		{
+			name: "no release at all",
+			want: []string{"v0.1.0", "v0.2.0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			releaseRepo(t, test.releases...)
+			tags, err := git.ListTags("v*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			missing, err := gh.MissingReleases(tags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(missing, test.want) {
+				t.Errorf("got missing releases %v, expected %v", missing, test.want)
+			}
+		})
+	}
+}
+
+func TestReleaseExistingTag(t *testing.T) {
+	tests := []struct {
+		name        string
+		releases    []string
+		wantCreated bool
+	}{
+		{
+			name:        "missing release created from the tag",
+			releases:    []string{"v0.1.0"},
+			wantCreated: true,
+		},
+		{
+			name:     "existing release left untouched",
+			releases: []string{"v0.1.0", "v0.2.0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logFile := releaseRepo(t, test.releases...)
+
+			changelog, created := release("v0.2.0", true, false, false, false, "auto")
+			if created != test.wantCreated {
+				t.Fatalf("got created %t, expected %t", created, test.wantCreated)
+			}
+			data, err := os.ReadFile(logFile) //nolint: gosec
+			if !test.wantCreated {
+				if !os.IsNotExist(err) {
+					t.Errorf("expected no release to be created, got %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := string(data)
+			if !strings.HasPrefix(args, "release create v0.2.0 -t v0.2.0 --verify-tag -n ") {
+				t.Errorf("release not created from the tag v0.2.0: gh %s", args)
+			}
+			// the changelog stops at the tag, the commit made after it isn't part of the release
+			if !strings.Contains(changelog, "second") || strings.Contains(changelog, "unreleased") {
+				t.Errorf("unexpected changelog for v0.2.0:\n%s", changelog)
+			}
+		})
+	}
+}
+
+func TestVerifyTagVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		wantErr    bool
+	}{
+		{
+			name:       "matching trailer",
+			annotation: "Release v0.2.0\n\nVersion: v0.2.0",
+		},
+		{
+			name:       "matching trailer without v prefix",
+			annotation: "Release v0.2.0\n\nVersion: 0.2.0",
+		},
+		{
+			name:       "trailer of a stale package.json",
+			annotation: "Release v0.3.0\n\nVersion: v0.3.0",
+			wantErr:    true,
+		},
+		{
+			name:       "no trailer",
+			annotation: "Release v0.3.0",
+		},
+		{
+			name: "lightweight tag",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			testutil.WriteFiles(t, ".", map[string]string{"package.json": `{"name": "perses-shared", "version": "0.2.0"}`})
+			testutil.Git(t, "add", "package.json")
+			testutil.Git(t, "commit", "--quiet", "--message", "[FEATURE] first")
+			if test.annotation == "" {
+				testutil.Git(t, "tag", "v0.2.0")
+			} else {
+				testutil.Git(t, "tag", "--annotate", "--message", test.annotation, "v0.2.0")
+			}
+			if err := verifyTagVersion("v0.2.0"); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestReleaseLatest(t *testing.T) {
+	tests := []struct {
+		name       string
+		release    string
+		makeLatest string
+		wantFlag   string
+	}{
+		{
+			name:       "stable release left to GitHub",
+			release:    "v0.2.0",
+			makeLatest: "auto",
+		},
+		{
+			name:       "prerelease never latest",
+			release:    "v0.3.0-rc.0",
+			makeLatest: "auto",
+			wantFlag:   "--latest=false",
+		},
+		{
+			name:       "stable release explicitly not latest",
+			release:    "v0.2.0",
+			makeLatest: "false",
+			wantFlag:   "--latest=false",
+		},
+		{
+			name:       "prerelease explicitly latest",
+			release:    "v0.3.0-rc.0",
+			makeLatest: "true",
+			wantFlag:   "--latest=true",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logFile := releaseRepo(t, "v0.1.0")
+			testutil.Git(t, "tag", "--annotate", "--message", "v0.3.0-rc.0", "v0.3.0-rc.0")
+
+			if _, created := release(test.release, true, false, false, false, test.makeLatest); !created {
+				t.Fatalf("release %s not created", test.release)
+			}
+			args := strings.Fields(testutil.ReadFile(t, logFile))
+			var flags []string
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--latest") {
+					flags = append(flags, arg)
+				}
+			}
+			var want []string
+			if test.wantFlag != "" {
+				want = []string{test.wantFlag}
+			}
+			if !slices.Equal(flags, want) {
+				t.Errorf("got flags %q, expected %q: gh %s", flags, want, strings.Join(args, " "))
+			}
+		})
+	}
+}
+
+func TestVerifyMajor(t *testing.T) {
+	tests := []struct {
+		name       string
+		release    string
+		allowMajor bool
+		wantErr    bool
+	}{
+		{
+			name:    "minor release",
+			release: "v1.1.0",
+		},
+		{
+			name:    "major release without --allow-major",
+			release: "v2.0.0",
+			wantErr: true,
+		},
+		{
+			name:       "major release with --allow-major",
+			release:    "v2.0.0",
+			allowMajor: true,
+		},
+		{
+			name:    "major prerelease without --allow-major",
+			release: "v2.0.0-rc.0",
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			testutil.Commit(t, "[FEATURE] first")
+			testutil.Git(t, "tag", "v1.0.0")
+			testutil.Commit(t, "[BREAKINGCHANGE] remove the legacy layout")
+			testutil.Git(t, "tag", test.release)
+
+			if err := verifyMajor(test.release, test.release, true, test.allowMajor); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
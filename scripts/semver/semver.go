@@ -0,0 +1,143 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// Version is a semantic version. Build metadata is ignored as it doesn't take part in the precedence.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease []string
+}
+
+// Parse parses a version in the format 1.2.3 or 1.2.3-rc.0, with or without a 'v' prefix.
+func Parse(version string) (Version, error) {
+	matches := versionPattern.FindStringSubmatch(version)
+	if matches == nil {
+		return Version{}, fmt.Errorf("invalid semantic version: %q", version)
+	}
+	v := Version{}
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	v.Patch, _ = strconv.Atoi(matches[3])
+	if matches[4] != "" {
+		v.Prerelease = strings.Split(matches[4], ".")
+	}
+	return v, nil
+}
+
+// IsPrerelease returns true if the version has a prerelease part, like 1.2.3-beta.0.
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+func (v Version) String() string {
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.IsPrerelease() {
+		version += "-" + strings.Join(v.Prerelease, ".")
+	}
+	return version
+}
+
+// Compare returns -1 if a is lower than b, 0 if they are equal and +1 if a is greater than b,
+// following the precedence rules of the semantic versioning specification.
+func Compare(a Version, b Version) int {
+	if c := cmp.Compare(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	// a version without prerelease has a higher precedence than the same version with a prerelease
+	switch {
+	case !a.IsPrerelease() && !b.IsPrerelease():
+		return 0
+	case !a.IsPrerelease():
+		return 1
+	case !b.IsPrerelease():
+		return -1
+	}
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a.Prerelease), len(b.Prerelease))
+}
+
+// compareIdentifier compares two prerelease identifiers: numeric ones are compared numerically
+// and have a lower precedence than alphanumeric ones, which are compared lexically.
+func compareIdentifier(a string, b string) int {
+	aNum, aErr := strconv.Atoi(a)
+	bNum, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmp.Compare(aNum, bNum)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// Bump is the kind of version increment between two releases, ordered from the smallest to the largest.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// Diff returns the bump applied to go from one version to the other. Prereleases are ignored.
+func Diff(from Version, to Version) Bump {
+	switch {
+	case to.Major > from.Major:
+		return BumpMajor
+	case to.Major == from.Major && to.Minor > from.Minor:
+		return BumpMinor
+	case to.Major == from.Major && to.Minor == from.Minor && to.Patch > from.Patch:
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
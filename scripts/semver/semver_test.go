@@ -0,0 +1,139 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semver
+
+import (
+	"slices"
+	"testing"
+)
+
+func mustParse(t *testing.T, version string) Version {
+	t.Helper()
+	v, err := Parse(version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Version
+		wantErr bool
+	}{
+		{version: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{version: "v0.53.0", want: Version{Minor: 53}},
+		{version: "1.0.0-rc.1", want: Version{Major: 1, Prerelease: []string{"rc", "1"}}},
+		{version: "1.0.0-beta.0+build.5", want: Version{Major: 1, Prerelease: []string{"beta", "0"}}},
+		{version: "1.0", wantErr: true},
+		{version: "1.0.0-", wantErr: true},
+		{version: "release-1.0.0", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.version, func(t *testing.T) {
+			got, err := Parse(test.version)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Major != test.want.Major || got.Minor != test.want.Minor || got.Patch != test.want.Patch || !slices.Equal(got.Prerelease, test.want.Prerelease) {
+				t.Errorf("got %+v, expected %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, version := range []string{"1.2.3", "0.53.0-beta.1"} {
+		if got := mustParse(t, "v"+version).String(); got != version {
+			t.Errorf("got %q, expected %q", got, version)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// ordered by precedence, following the example of the semantic versioning specification
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			if got := Compare(mustParse(t, ordered[i]), mustParse(t, ordered[j])); got != want {
+				t.Errorf("Compare(%s, %s) = %d, expected %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want Bump
+	}{
+		{from: "1.2.3", to: "1.2.3", want: BumpNone},
+		{from: "1.2.3", to: "1.2.4", want: BumpPatch},
+		{from: "1.2.3", to: "1.3.0", want: BumpMinor},
+		{from: "1.2.3", to: "2.0.0", want: BumpMajor},
+		{from: "1.2.3", to: "1.1.0", want: BumpNone},
+		{from: "1.2.3-rc.0", to: "1.2.3", want: BumpNone},
+		{from: "0.53.0", to: "0.54.0-beta.0", want: BumpMinor},
+	}
+	for _, test := range tests {
+		if got := Diff(mustParse(t, test.from), mustParse(t, test.to)); got != test.want {
+			t.Errorf("Diff(%s, %s) = %s, expected %s", test.from, test.to, got, test.want)
+		}
+	}
+}
+
+func TestBumpString(t *testing.T) {
+	tests := []struct {
+		bump Bump
+		want string
+	}{
+		{bump: BumpNone, want: "none"},
+		{bump: BumpPatch, want: "patch"},
+		{bump: BumpMinor, want: "minor"},
+		{bump: BumpMajor, want: "major"},
+	}
+	for _, test := range tests {
+		if got := test.bump.String(); got != test.want {
+			t.Errorf("got %q, expected %q", got, test.want)
+		}
+	}
+}
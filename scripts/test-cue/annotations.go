@@ -0,0 +1,51 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// annotationDataEscaper escapes the message of a workflow command.
+	annotationDataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	// annotationPropertyEscaper escapes the properties (e.g. file=) of a workflow command.
+	annotationPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// writeAnnotations writes the diagnostics as GitHub Actions `::error` workflow commands, so that they are shown inline on the
+// files of the pull request. The first position of a diagnostic is the annotated one, the others are listed in the message.
+func writeAnnotations(w io.Writer, diagnostics []diagnostic) error {
+	for _, d := range diagnostics {
+		message := d.Message
+		if len(d.Positions) > 1 {
+			var related []string
+			for _, p := range d.Positions[1:] {
+				related = append(related, fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column))
+			}
+			message = fmt.Sprintf("%s\nsee also: %s", message, strings.Join(related, ", "))
+		}
+		properties := "title=" + annotationPropertyEscaper.Replace("CUE validation failure")
+		if len(d.Positions) > 0 {
+			p := d.Positions[0]
+			properties = fmt.Sprintf("file=%s,line=%d,col=%d,%s", annotationPropertyEscaper.Replace(p.File), p.Line, p.Column, properties)
+		}
+		if _, err := fmt.Fprintf(w, "::error %s::%s\n", properties, annotationDataEscaper.Replace(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/mod/modconfig"
+	"github.com/sirupsen/logrus"
+)
+
+// casesSuffix is the suffix of the test files holding a table of cases, e.g.:
+//
+//	cases: {
+//		"http URL": {schema: #url, input: "http://localhost:9090", valid: true}
+//		"missing scheme": {schema: #url, input: "localhost:9090", valid: false}
+//	}
+//
+// Each case is vetted on its own: its input is unified with its schema and must be concrete, then the result must match valid.
+const casesSuffix = "_cases.cue"
+
+// casesField is the field of a cases file holding the cases, by name.
+const casesField = "cases"
+
+// vetCases vets each case of the given cases file against the schema files of the package, see casesSuffix.
+func vetCases(w io.Writer, ctx *cue.Context, registry modconfig.Registry, label string, schemaFiles []string, casesFile string) ([]diagnostic, error) {
+	value, err := loadFiles(ctx, registry, append(slices.Clone(schemaFiles), casesFile))
+	if err != nil {
+		return reportErrors(w, err), fmt.Errorf("failed to load %s with %s", label, casesFile)
+	}
+	cases := value.LookupPath(cue.ParsePath(casesField))
+	if !cases.Exists() {
+		return nil, fmt.Errorf("%s has no %s field", casesFile, casesField)
+	}
+	iter, err := cases.Fields()
+	if err != nil {
+		return reportErrors(w, err), fmt.Errorf("%s of %s must be a struct of cases", casesField, casesFile)
+	}
+
+	var diagnostics []diagnostic
+	count, failures := 0, 0
+	for iter.Next() {
+		name, c := iter.Selector().Unquoted(), iter.Value()
+		count++
+		schema, input := c.LookupPath(cue.ParsePath("schema")), c.LookupPath(cue.ParsePath("input"))
+		valid, err := c.LookupPath(cue.ParsePath("valid")).Bool()
+		if !schema.Exists() || !input.Exists() || err != nil {
+			return nil, fmt.Errorf("case %q of %s must have a schema, an input and a boolean valid field", name, casesFile)
+		}
+		vetErr := schema.Unify(input).Validate(cue.Concrete(true))
+		switch {
+		case vetErr == nil && !valid:
+			logrus.Errorf("Case %q of %s is expected to be rejected, but it's valid", name, casesFile)
+			diagnostics = append(diagnostics, diagnostic{
+				Message:   fmt.Sprintf("case %q is expected to be rejected, but it's valid", name),
+				Positions: []position{toPosition(c.Pos())},
+			})
+			failures++
+		case vetErr != nil && valid:
+			logrus.Errorf("Case %q of %s is expected to be valid, but it's rejected", name, casesFile)
+			diagnostics = append(diagnostics, reportErrors(w, vetErr)...)
+			failures++
+		default:
+			logrus.Debugf("Case %q of %s passed", name, casesFile)
+		}
+	}
+	if failures > 0 {
+		return diagnostics, fmt.Errorf("%d of %d case(s) of %s failed", failures, count, filepath.Base(casesFile))
+	}
+	logrus.Debugf("%d case(s) of %s passed", count, casesFile)
+	return nil, nil
+}
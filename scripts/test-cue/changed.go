@@ -0,0 +1,99 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"github.com/perses/shared/scripts/git"
+	"github.com/sirupsen/logrus"
+)
+
+// changedPackages returns the packages of cue/ impacted by the changes since the given reference: the packages whose schemas
+// or tests changed, and, transitively, the packages importing them. The packages are relative to cue/, like with -package.
+// It returns a nil map when every package is impacted, i.e. when the content of cue.mod changed.
+func changedPackages(base string) (map[string]bool, error) {
+	files, err := git.ChangedFiles(base, schemasDir, testDir)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(map[string]bool)
+	for _, file := range files {
+		packageDir, ok := packageOf(filepath.FromSlash(file))
+		if !ok {
+			continue
+		}
+		if packageDir == "cue.mod" || strings.HasPrefix(packageDir, "cue.mod"+string(filepath.Separator)) {
+			logrus.Infof("%s changed since %s, all the packages are impacted", file, base)
+			return nil, nil
+		}
+		changed[packageDir] = true
+	}
+
+	moduleFile, err := readModuleFile()
+	if err != nil {
+		return nil, err
+	}
+	imports, err := fileImports(schemasDir, testDir)
+	if err != nil {
+		return nil, err
+	}
+	// importers maps each package of the module to the packages importing it
+	importers := make(map[string][]string)
+	for importPath, importingFiles := range imports {
+		imported, ok := strings.CutPrefix(ast.ParseImportPath(importPath).Path, moduleFile.ModulePath()+"/")
+		if !ok {
+			continue
+		}
+		for _, file := range importingFiles {
+			if importer, ok := packageOf(file); ok {
+				importers[filepath.FromSlash(imported)] = append(importers[filepath.FromSlash(imported)], importer)
+			}
+		}
+	}
+
+	queue := slices.Collect(maps.Keys(changed))
+	for len(queue) > 0 {
+		packageDir := queue[0]
+		queue = queue[1:]
+		for _, importer := range importers[packageDir] {
+			if !changed[importer] {
+				logrus.Debugf("Package %s imports the changed package %s", importer, packageDir)
+				changed[importer] = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+	return changed, nil
+}
+
+// packageOf returns the package, relative to cue/, that the given file of cue/ or cue-test/ belongs to.
+func packageOf(file string) (string, bool) {
+	for _, root := range []string{schemasDir, testDir} {
+		rel, err := filepath.Rel(root, filepath.Dir(file))
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return rel, true
+		}
+	}
+	return "", false
+}
+
+// formatPackages lists the given packages for the logs.
+func formatPackages(packages map[string]bool) string {
+	return strings.Join(slices.Sorted(maps.Keys(packages)), ", ")
+}
@@ -0,0 +1,89 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestChangedPackages(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes map[string]string
+		wantAll bool
+		want    []string
+	}{
+		{
+			name: "no change",
+		},
+		{
+			name:    "schema changed",
+			changes: map[string]string{"cue/other/other.cue": "package other\n\n#Other: int\n"},
+			want:    []string{"other"},
+		},
+		{
+			name:    "test changed",
+			changes: map[string]string{"cue-test/other/other.cue": "package other\n\nother: #Other & \"changed\"\n"},
+			want:    []string{"other"},
+		},
+		{
+			name:    "imported schema changed",
+			changes: map[string]string{"cue/common/format.cue": "package common\n\n#Format: {unit!: string}\n"},
+			want:    []string{"common", "panels", "panels/timeseries"},
+		},
+		{
+			name:    "module changed",
+			changes: map[string]string{"cue/cue.mod/module.cue": testModule + "// comment\n"},
+			wantAll: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			testutil.WriteFiles(t, ".", map[string]string{
+				"cue/cue.mod/module.cue":               testModule,
+				"cue/common/format.cue":                "package common\n\n#Format: {unit?: string}\n",
+				"cue/panels/panel.cue":                 "package panels\n\nimport \"github.com/perses/test/common\"\n\n#Panel: {format?: common.#Format}\n",
+				"cue/panels/timeseries/timeseries.cue": "package timeseries\n\nimport \"github.com/perses/test/panels\"\n\n#TimeSeries: panels.#Panel\n",
+				"cue/other/other.cue":                  "package other\n\n#Other: string\n",
+				"cue-test/other/other.cue":             "package other\n\nother: #Other & \"other\"\n",
+				"README.md":                            "# test\n",
+			})
+			testutil.Git(t, "add", ".")
+			testutil.Git(t, "commit", "--quiet", "--message", "schemas")
+			testutil.WriteFiles(t, ".", test.changes)
+
+			changed, err := changedPackages("HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantAll {
+				if changed != nil {
+					t.Errorf("got packages %q, expected all of them", formatPackages(changed))
+				}
+				return
+			}
+			if changed == nil {
+				t.Fatalf("got all the packages, expected %q", test.want)
+			}
+			if got := slices.Sorted(maps.Keys(changed)); !slices.Equal(got, test.want) {
+				t.Errorf("got packages %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/mod/modconfig"
+	"github.com/perses/shared/scripts/git"
+	"github.com/sirupsen/logrus"
+)
+
+// schemaPackage is a built CUE package of a schema directory, which can hold several of them.
+type schemaPackage struct {
+	name  string
+	value cue.Value
+}
+
+// loadPackages loads and builds each package of packageDir, relative to the CUE module in moduleDir.
+// Like in vetPackage, the files are grouped by their package clause, and the packages are returned sorted by name.
+// A directory without .cue file has no package.
+func loadPackages(ctx *cue.Context, registry modconfig.Registry, moduleDir string, packageDir string) ([]schemaPackage, error) {
+	files, err := filepath.Glob(filepath.Join(moduleDir, packageDir, "*.cue"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob schema files: %w", err)
+	}
+	_, names, err := packageFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]schemaPackage, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			// files without package clause can't be imported
+			continue
+		}
+		instances := load.Instances([]string{"./" + filepath.ToSlash(packageDir) + ":" + name}, &load.Config{Dir: moduleDir, Registry: registry})
+		if len(instances) != 1 {
+			return nil, fmt.Errorf("expected a single instance for package %s in %s, got %d", name, packageDir, len(instances))
+		}
+		if err := instances[0].Err; err != nil {
+			return nil, err
+		}
+		value := ctx.BuildInstance(instances[0])
+		if err := value.Err(); err != nil {
+			return nil, err
+		}
+		packages = append(packages, schemaPackage{name: name, value: value})
+	}
+	return packages, nil
+}
+
+// packageLabel identifies a package of packageDir: the directory alone when it holds a single package,
+// otherwise the directory qualified with the name of the package, the way CUE imports it (e.g. common:legacy).
+func packageLabel(packageDir string, name string, count int) string {
+	if count > 1 {
+		return fmt.Sprintf("%s:%s", packageDir, name)
+	}
+	return packageDir
+}
+
+// incompatibilities compares the definitions of a package with the ones of the previous version, and reports the definitions
+// that were removed or that don't accept all the values accepted by the previous version anymore.
+func incompatibilities(previous cue.Value, current cue.Value) ([]string, error) {
+	var issues []string
+	iter, err := previous.Fields(cue.Definitions(true))
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		selector := iter.Selector()
+		if !selector.IsDefinition() {
+			continue
+		}
+		definition := current.LookupPath(cue.MakePath(selector))
+		if !definition.Exists() {
+			issues = append(issues, fmt.Sprintf("%s has been removed", selector))
+			continue
+		}
+		// unchanged definitions are skipped, as the subsumption of builtin validators like strings.MinRunes is not precise
+		if fmt.Sprint(definition) == fmt.Sprint(iter.Value()) {
+			continue
+		}
+		if err := definition.Subsume(iter.Value(), cue.Schema()); err != nil {
+			issues = append(issues, fmt.Sprintf("%s doesn't accept all the values of the previous version anymore: %v", selector, err))
+		}
+	}
+	return issues, nil
+}
+
+// checkCompatibility verifies that the schema packages accept every value accepted by their version as of ref.
+func checkCompatibility(registry modconfig.Registry, ref string, dirsInScope []string, excluded []string) error {
+	tmpDir, err := os.MkdirTemp("", "test-cue-compat-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := git.ExtractTree(ref, schemasDir, tmpDir); err != nil {
+		return err
+	}
+	previousSchemasDir := filepath.Join(tmpDir, schemasDir)
+
+	ctx := cuecontext.New()
+
+	issuesCount := 0
+	for _, dirInScope := range dirsInScope {
+		if _, err := os.Stat(filepath.Join(previousSchemasDir, dirInScope)); os.IsNotExist(err) {
+			continue
+		}
+		packageDirs, err := findPackages(previousSchemasDir, dirInScope)
+		if err != nil {
+			return fmt.Errorf("failed to find directories in %s/%s at %s: %w", schemasDir, dirInScope, ref, err)
+		}
+		for _, packageDir := range packageDirs {
+			if isUnder(packageDir, excluded) {
+				continue
+			}
+			schemaDir := filepath.Join(schemasDir, packageDir)
+			previousPackages, err := loadPackages(ctx, registry, previousSchemasDir, packageDir)
+			if err != nil {
+				return fmt.Errorf("failed to load %s at %s: %w", schemaDir, ref, err)
+			}
+			if len(previousPackages) == 0 {
+				continue
+			}
+			if _, err := os.Stat(schemaDir); os.IsNotExist(err) {
+				logrus.Errorf("Package %s has been removed since %s", schemaDir, ref)
+				issuesCount++
+				continue
+			}
+			currentPackages, err := loadPackages(ctx, registry, schemasDir, packageDir)
+			if err != nil {
+				return fmt.Errorf("failed to load %s: %w", schemaDir, err)
+			}
+			for _, previous := range previousPackages {
+				label := packageLabel(schemaDir, previous.name, len(previousPackages))
+				i := slices.IndexFunc(currentPackages, func(p schemaPackage) bool { return p.name == previous.name })
+				if i < 0 {
+					logrus.Errorf("Package %s has been removed since %s", label, ref)
+					issuesCount++
+					continue
+				}
+				issues, err := incompatibilities(previous.value, currentPackages[i].value)
+				if err != nil {
+					return fmt.Errorf("failed to compare %s with its version at %s: %w", label, ref, err)
+				}
+				for _, issue := range issues {
+					logrus.Errorf("Package %s: %s", label, issue)
+				}
+				issuesCount += len(issues)
+			}
+		}
+	}
+	if issuesCount > 0 {
+		return fmt.Errorf("%d backward-incompatible change(s) since %s", issuesCount, ref)
+	}
+	logrus.Infof("✓ Schemas backward-compatible with %s", ref)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+)
+
+// parseCueFiles parses the .cue files of dir, along with their comments.
+func parseCueFiles(dir string) ([]*ast.File, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.cue"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob files in %s: %w", dir, err)
+	}
+	files := make([]*ast.File, 0, len(paths))
+	for _, path := range paths {
+		file, err := parser.ParseFile(path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// topLevelDefinitions returns the fields declaring the exported definitions at the top level of the files, such as `#Format: {`,
+// in their order of declaration. Hidden definitions (`_#name`) are not exported and thus not returned.
+func topLevelDefinitions(files []*ast.File) []*ast.Field {
+	var fields []*ast.Field
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			field, ok := decl.(*ast.Field)
+			if !ok {
+				continue
+			}
+			if name, _, _ := ast.LabelName(field.Label); strings.HasPrefix(name, "#") {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// definitions returns the distinct top-level definitions of the schema package.
+func definitions(schemaDir string) ([]string, error) {
+	files, err := parseCueFiles(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+	var defs []string
+	for _, field := range topLevelDefinitions(files) {
+		if name, _, _ := ast.LabelName(field.Label); !slices.Contains(defs, name) {
+			defs = append(defs, name)
+		}
+	}
+	return defs, nil
+}
+
+// uncoveredDefinitions returns the top-level definitions of the schema package that are never referenced by the test package,
+// along with the total number of definitions of the package.
+// A definition is referenced by an identifier, either directly or selected from the imported schema package (e.g. `common.#Format`).
+func uncoveredDefinitions(schemaDir, testDir string) ([]string, int, error) {
+	defs, err := definitions(schemaDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	tests, err := parseCueFiles(testDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	referenced := make(map[string]bool)
+	for _, test := range tests {
+		ast.Walk(test, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Ident); ok {
+				referenced[ident.Name] = true
+			}
+			return true
+		}, nil)
+	}
+
+	var uncovered []string
+	for _, definition := range defs {
+		if !referenced[definition] {
+			uncovered = append(uncovered, definition)
+		}
+	}
+	return uncovered, len(defs), nil
+}
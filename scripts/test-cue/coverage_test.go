@@ -0,0 +1,84 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestUncoveredDefinitions(t *testing.T) {
+	const schema = `package common
+
+#Format: {
+	unit?: string
+}
+
+#FormatOptions?: {
+	decimalPlaces?: int
+}
+
+#Format: unit?: =~"^[a-z]+$"
+`
+	tests := []struct {
+		name          string
+		test          string
+		wantUncovered []string
+	}{
+		{
+			name:          "one of two definitions referenced",
+			test:          "package common\n\nformat: #Format & {unit: \"bytes\"}\n",
+			wantUncovered: []string{"#FormatOptions"},
+		},
+		{
+			name: "both definitions referenced",
+			test: "package common\n\nformat: #Format & {unit: \"bytes\"}\noptions: #FormatOptions & {decimalPlaces: 2}\n",
+		},
+		{
+			name:          "definition prefix not counted as a reference",
+			test:          "package common\n\noptions: #FormatOptions & {decimalPlaces: 2}\n",
+			wantUncovered: []string{"#Format"},
+		},
+		{
+			name: "definitions selected from the imported schema package",
+			test: "package common\n\nimport c \"github.com/perses/test/common\"\n\nformat: c.#Format & {unit: \"bytes\"}\noptions: c.#FormatOptions\n",
+		},
+		{
+			name:          "definition mentioned in a comment only",
+			test:          "package common\n\n// #FormatOptions is tested elsewhere.\nformat: #Format & {unit: \"bytes\"}\n",
+			wantUncovered: []string{"#FormatOptions"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"cue/common/format.cue":      schema,
+				"cue-test/common/format.cue": test.test,
+			})
+			uncovered, total, err := uncoveredDefinitions("cue/common", "cue-test/common")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 2 {
+				t.Errorf("got %d definitions, expected 2", total)
+			}
+			if !slices.Equal(uncovered, test.wantUncovered) {
+				t.Errorf("got uncovered definitions %q, expected %q", uncovered, test.wantUncovered)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/encoding/json"
+	"cuelang.org/go/encoding/yaml"
+	"github.com/sirupsen/logrus"
+)
+
+// dataExtensions are the extensions of the data fixtures of a test package, vetted against a definition of the schemas.
+var dataExtensions = []string{".json", ".yaml", ".yml"}
+
+// isDataFile returns true if the file of a test package is a data fixture, golden files excluded.
+func isDataFile(f string) bool {
+	if strings.HasSuffix(f, goldenSuffix) {
+		return false
+	}
+	for _, ext := range dataExtensions {
+		if filepath.Ext(f) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// dataDefinition returns the definition a data fixture is vetted against, from its name in the format <name>.<definition>.<ext>,
+// e.g. #format for percent.format.json. It also returns whether the data is expected to be rejected, i.e. when the name ends with _invalid.
+func dataDefinition(f string) (string, bool, error) {
+	parts := strings.Split(filepath.Base(f), ".")
+	if len(parts) != 3 {
+		return "", false, fmt.Errorf("data file %s must be named <name>.<definition>%s to know the definition to vet it against", f, filepath.Ext(f))
+	}
+	return "#" + parts[1], strings.HasSuffix(parts[0], strings.TrimSuffix(invalidSuffix, ".cue")), nil
+}
+
+// buildData builds the value of a JSON or YAML data file.
+func buildData(ctx *cue.Context, f string) (cue.Value, error) {
+	data, err := os.ReadFile(f) //nolint: gosec
+	if err != nil {
+		return cue.Value{}, fmt.Errorf("failed to read %s: %w", f, err)
+	}
+	if filepath.Ext(f) == ".json" {
+		expr, err := json.Extract(f, data)
+		if err != nil {
+			return cue.Value{}, err
+		}
+		return ctx.BuildExpr(expr), nil
+	}
+	file, err := yaml.Extract(f, data)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	return ctx.BuildFile(file), nil
+}
+
+// vetDataFiles vets each data fixture against the definition of the schemas given by its name, the way `cue vet -d` does.
+// When the directory holds several packages, the definition is looked up in each of them, in order.
+// Like the CUE test files, the fixtures whose name ends with _invalid must be rejected.
+func vetDataFiles(w io.Writer, ctx *cue.Context, schemas []cue.Value, dataFiles []string) ([]diagnostic, error) {
+	var diagnostics []diagnostic
+	failures := 0
+	for _, f := range dataFiles {
+		definition, invalid, err := dataDefinition(f)
+		if err != nil {
+			return nil, err
+		}
+		var schema cue.Value
+		for _, pkg := range schemas {
+			if schema = pkg.LookupPath(cue.ParsePath(definition)); schema.Exists() {
+				break
+			}
+		}
+		if !schema.Exists() {
+			return nil, fmt.Errorf("definition %s of data file %s not found in the schemas", definition, f)
+		}
+		data, err := buildData(ctx, f)
+		if err != nil {
+			return reportErrors(w, err), fmt.Errorf("failed to parse %s", f)
+		}
+		vetErr := schema.Unify(data).Validate(cue.Concrete(true))
+		switch {
+		case invalid && vetErr == nil:
+			logrus.Errorf("%s is expected to be rejected by %s, but it's valid", f, definition)
+			diagnostics = append(diagnostics, diagnostic{
+				Message:   fmt.Sprintf("expected to be rejected by %s, but it's valid", definition),
+				Positions: []position{{File: filepath.ToSlash(f), Line: 1, Column: 1}},
+			})
+			failures++
+		case !invalid && vetErr != nil:
+			diagnostics = append(diagnostics, reportErrors(w, vetErr)...)
+			failures++
+		}
+	}
+	if failures > 0 {
+		return diagnostics, fmt.Errorf("%d data file(s) not vetted as expected", failures)
+	}
+	return nil, nil
+}
@@ -0,0 +1,100 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestVetDataFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		dataFiles map[string]string
+		wantErr   string
+	}{
+		{
+			name:      "valid JSON data",
+			dataFiles: map[string]string{"percent.format.json": `{"unit": "percent"}`},
+		},
+		{
+			name:      "valid YAML data",
+			dataFiles: map[string]string{"percent.format.yaml": "unit: percent\n", "bytes.format.yml": "unit: bytes\n"},
+		},
+		{
+			name:      "definition of the second package",
+			dataFiles: map[string]string{"local.timezone.json": `"local"`},
+		},
+		{
+			name:      "data rejected by its definition",
+			dataFiles: map[string]string{"percent.format.json": `{"unit": 100}`},
+			wantErr:   "1 data file(s) not vetted as expected",
+		},
+		{
+			name:      "incomplete data",
+			dataFiles: map[string]string{"empty.format.json": `{}`},
+			wantErr:   "1 data file(s) not vetted as expected",
+		},
+		{
+			name:      "invalid data rejected",
+			dataFiles: map[string]string{"percent_invalid.format.json": `{"unit": 100}`},
+		},
+		{
+			name:      "invalid data accepted",
+			dataFiles: map[string]string{"percent_invalid.format.yaml": "unit: percent\n"},
+			wantErr:   "1 data file(s) not vetted as expected",
+		},
+		{
+			name:      "name without definition",
+			dataFiles: map[string]string{"percent.json": `{"unit": "percent"}`},
+			wantErr:   "must be named <name>.<definition>.json",
+		},
+		{
+			name:      "unknown definition",
+			dataFiles: map[string]string{"percent.unit.json": `"percent"`},
+			wantErr:   "definition #unit of data file",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			testutil.WriteFiles(t, dir, test.dataFiles)
+			var dataFiles []string
+			for name := range test.dataFiles {
+				dataFiles = append(dataFiles, filepath.Join(dir, name))
+			}
+			ctx := cuecontext.New()
+			schemas := []cue.Value{
+				ctx.CompileString("#format: {unit: string}"),
+				ctx.CompileString("#timezone: \"local\" | \"UTC\""),
+			}
+			_, err := vetDataFiles(io.Discard, ctx, schemas, dataFiles)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
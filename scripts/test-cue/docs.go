@@ -0,0 +1,46 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cuelang.org/go/cue/ast"
+)
+
+// undocumentedDefinitions returns the exported top-level definitions of the schema package that aren't directly
+// preceded by a doc comment, in the `<file>:<line>: <definition>` format.
+// Hidden definitions (`_#name`) are not exported and thus not reported.
+func undocumentedDefinitions(schemaDir string) ([]string, error) {
+	files, err := parseCueFiles(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+	var undocumented []string
+	for _, field := range topLevelDefinitions(files) {
+		if !hasDocComment(field) {
+			name, _, _ := ast.LabelName(field.Label)
+			undocumented = append(undocumented, violation(field.Pos(), name))
+		}
+	}
+	return undocumented, nil
+}
+
+// hasDocComment returns true if the field is directly preceded by a comment, without blank line in between.
+func hasDocComment(field *ast.Field) bool {
+	for _, cg := range ast.Comments(field) {
+		if cg.Doc {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestUndocumentedDefinitions(t *testing.T) {
+	tests := []struct {
+		name             string
+		schema           string
+		wantUndocumented []string
+	}{
+		{
+			name:   "documented definition",
+			schema: "package common\n\n// #Format is the format of a value.\n#Format: {\n\tunit?: string\n}\n",
+		},
+		{
+			name:             "undocumented definition",
+			schema:           "package common\n\n#Format: {\n\tunit?: string\n}\n",
+			wantUndocumented: []string{"format.cue:3: #Format"},
+		},
+		{
+			name:             "comment separated by a blank line",
+			schema:           "package common\n\n// #Format is the format of a value.\n\n#Format: {\n\tunit?: string\n}\n",
+			wantUndocumented: []string{"format.cue:5: #Format"},
+		},
+		{
+			name:             "optional definition",
+			schema:           "package common\n\n#Format?: {\n\tunit?: string\n}\n",
+			wantUndocumented: []string{"format.cue:3: #Format"},
+		},
+		{
+			name:   "nested definition",
+			schema: "package common\n\n// #Format is the format of a value.\n#Format: {\n\t#Unit: string\n\tunit?: #Unit\n}\n",
+		},
+		{
+			name:   "hidden definition",
+			schema: "package common\n\n_#unit: string\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			testutil.WriteFiles(t, dir, map[string]string{"format.cue": test.schema})
+			undocumented, err := undocumentedDefinitions(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, u := range test.wantUndocumented {
+				want = append(want, filepath.Join(dir, u))
+			}
+			if !slices.Equal(undocumented, want) {
+				t.Errorf("got %q, expected %q", undocumented, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"cuelang.org/go/cue/format"
+	"github.com/perses/shared/scripts/diff"
+)
+
+// unformattedFiles returns the .cue files under the given directories that are not formatted the way `cue fmt` does,
+// and prints the diff to apply to each of them. The cue.mod directories are ignored.
+func unformattedFiles(dirs ...string) ([]string, error) {
+	var unformatted []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if d.Name() == "cue.mod" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) != ".cue" {
+				return nil
+			}
+			data, err := os.ReadFile(path) //nolint: gosec
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			formatted, err := format.Source(data)
+			if err != nil {
+				return fmt.Errorf("failed to format %s: %w", path, err)
+			}
+			if !bytes.Equal(data, formatted) {
+				fmt.Fprint(os.Stderr, diff.Unified(path, data, formatted))
+				unformatted = append(unformatted, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return unformatted, nil
+}
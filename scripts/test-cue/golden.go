@@ -0,0 +1,87 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/mod/modconfig"
+	"github.com/perses/shared/scripts/diff"
+	"github.com/sirupsen/logrus"
+)
+
+// goldenSuffix is the suffix of the file holding the expected export of a test file, e.g. format.golden.json for format.cue.
+// It catches unintended changes to the defaults and computed fields of the schemas.
+const goldenSuffix = ".golden.json"
+
+// updateGolden makes checkGoldens write the golden files instead of comparing them.
+var updateGolden bool
+
+// checkGoldens exports each test file merged with the schemas, the way `cue export` does, and compares the result
+// with its golden file. The test files without golden file are ignored, unless the golden files are being updated.
+func checkGoldens(w io.Writer, ctx *cue.Context, registry modconfig.Registry, schemaFiles []string, testFiles []string) ([]diagnostic, error) {
+	var diagnostics []diagnostic
+	for _, f := range testFiles {
+		goldenPath := strings.TrimSuffix(f, ".cue") + goldenSuffix
+		golden, err := os.ReadFile(goldenPath) //nolint: gosec
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s: %w", goldenPath, err)
+		}
+		if golden == nil && !updateGolden {
+			continue
+		}
+
+		value, err := loadFiles(ctx, registry, append(slices.Clone(schemaFiles), f))
+		if err != nil {
+			return reportErrors(w, err), fmt.Errorf("failed to load %s", f)
+		}
+		exported, err := value.MarshalJSON()
+		if err != nil {
+			return reportErrors(w, err), fmt.Errorf("failed to export %s", f)
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, exported, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to indent the export of %s: %w", f, err)
+		}
+		indented.WriteByte('\n')
+
+		if updateGolden {
+			if err := os.WriteFile(goldenPath, indented.Bytes(), 0644); err != nil { //nolint: gosec
+				return nil, fmt.Errorf("failed to write %s: %w", goldenPath, err)
+			}
+			logrus.Infof("Golden file %s updated", goldenPath)
+			continue
+		}
+		if !bytes.Equal(golden, indented.Bytes()) {
+			fmt.Fprint(w, diff.Unified(goldenPath, golden, indented.Bytes()))
+			diagnostics = append(diagnostics, diagnostic{
+				Message:   fmt.Sprintf("the export of %s doesn't match %s, run with -update to regenerate it", filepath.Base(f), filepath.Base(goldenPath)),
+				Positions: []position{{File: filepath.ToSlash(goldenPath), Line: 1, Column: 1}},
+			})
+		}
+	}
+	if len(diagnostics) > 0 {
+		return diagnostics, fmt.Errorf("%d golden file(s) don't match", len(diagnostics))
+	}
+	return nil, nil
+}
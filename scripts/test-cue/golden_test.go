@@ -0,0 +1,99 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestCheckGoldens(t *testing.T) {
+	const export = "{\n  \"format\": {\n    \"unit\": \"bytes\"\n  }\n}\n"
+	tests := []struct {
+		name       string
+		golden     *string
+		update     bool
+		wantErr    string
+		wantGolden *string
+	}{
+		{
+			name:       "matching golden file",
+			golden:     new(export),
+			wantGolden: new(export),
+		},
+		{
+			name:       "golden file out of date",
+			golden:     new("{\n  \"format\": {\n    \"unit\": \"percent\"\n  }\n}\n"),
+			wantErr:    "1 golden file(s) don't match",
+			wantGolden: new("{\n  \"format\": {\n    \"unit\": \"percent\"\n  }\n}\n"),
+		},
+		{
+			name: "no golden file",
+		},
+		{
+			name:       "golden file updated",
+			golden:     new("{}\n"),
+			update:     true,
+			wantGolden: new(export),
+		},
+		{
+			name:       "golden file created",
+			update:     true,
+			wantGolden: new(export),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			files := map[string]string{
+				"cue/cue.mod/module.cue":     testModule,
+				"cue/common/format.cue":      "package common\n\n#Format: {unit: string | *\"bytes\"}\n",
+				"cue-test/common/format.cue": "package common\n\nformat: #Format & {}\n",
+			}
+			if test.golden != nil {
+				files["cue-test/common/format.golden.json"] = *test.golden
+			}
+			testutil.WriteFiles(t, ".", files)
+			updateGolden = test.update
+			t.Cleanup(func() { updateGolden = false })
+
+			diagnostics, err := checkGoldens(io.Discard, cuecontext.New(), nil, []string{"cue/common/format.cue"}, []string{"cue-test/common/format.cue"})
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("got error %v, expected %q", err, test.wantErr)
+				}
+				if len(diagnostics) != 1 || diagnostics[0].Positions[0].File != "cue-test/common/format.golden.json" {
+					t.Errorf("got diagnostics %v, expected one on the golden file", diagnostics)
+				}
+			}
+
+			golden, err := os.ReadFile("cue-test/common/format.golden.json")
+			switch {
+			case test.wantGolden == nil && !os.IsNotExist(err):
+				t.Errorf("got golden file %q (%v), expected none", golden, err)
+			case test.wantGolden != nil && string(golden) != *test.wantGolden:
+				t.Errorf("got golden file %q (%v), expected %q", golden, err, *test.wantGolden)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/mod/modconfig"
+	"cuelang.org/go/mod/module"
+	"github.com/perses/shared/scripts/config"
+	"github.com/sirupsen/logrus"
+)
+
+// verifyImports checks that every import of the schemas and their tests resolves to a package: a package of the module
+// must exist under cue/ with the imported package name, and any other module must be declared in cue.mod/module.cue and
+// hold the imported package at the version it's pinned to, which is fetched from the registry.
+// Unlike the validation, it covers the whole tree, so that a dangling import is reported even if its package isn't vetted.
+func verifyImports(registry modconfig.Registry) error {
+	moduleFile, err := readModuleFile()
+	if err != nil {
+		return err
+	}
+	imports, err := fileImports(schemasDir, testDir)
+	if err != nil {
+		return err
+	}
+
+	var issues []string
+	modules := make(map[module.Version]*fetchedModule)
+	for importPath, files := range imports {
+		ip := ast.ParseImportPath(importPath)
+		// the standard library packages don't have a domain name as first element
+		if !strings.Contains(strings.Split(ip.Path, "/")[0], ".") {
+			continue
+		}
+		if rel, ok := strings.CutPrefix(ip.Path, moduleFile.ModulePath()); ok && (rel == "" || strings.HasPrefix(rel, "/")) {
+			if err := verifyPackage(os.DirFS("."), path.Join(schemasDir, rel), ip.Qualifier); err != nil {
+				issues = append(issues, fmt.Sprintf("%s imported by %s: %v", importPath, strings.Join(files, ", "), err))
+			}
+			continue
+		}
+		mv, ok := moduleFile.ModuleForImportPath(importPath)
+		if !ok {
+			issues = append(issues, fmt.Sprintf("%s imported by %s: module not declared in %s", importPath, strings.Join(files, ", "), filepath.Join(schemasDir, "cue.mod", "module.cue")))
+			continue
+		}
+		fetched, ok := modules[mv]
+		if !ok {
+			fetched = fetchModule(registry, mv)
+			modules[mv] = fetched
+		}
+		if fetched.err != nil {
+			// the registry may only be unreachable, e.g. when working offline
+			continue
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(ip.Path, mv.BasePath()), "/")
+		if err := verifyPackage(fetched.loc.FS, path.Join(fetched.loc.Dir, rel), ip.Qualifier); err != nil {
+			issues = append(issues, fmt.Sprintf("%s imported by %s: not found in %s: %v", importPath, strings.Join(files, ", "), mv, err))
+		}
+	}
+
+	unverified := 0
+	for mv, fetched := range modules {
+		if fetched.err == nil {
+			continue
+		}
+		unverified++
+		if warnErr := config.Warnf("unable to verify the imports of %s, the module cannot be fetched: %v", mv, fetched.err); warnErr != nil {
+			issues = append(issues, warnErr.Error())
+		}
+	}
+	if len(issues) > 0 {
+		slices.Sort(issues)
+		return fmt.Errorf("%d dangling import(s):\n  %s", len(issues), strings.Join(issues, "\n  "))
+	}
+	if unverified == 0 {
+		logrus.Infof("✓ All the imports of %s and %s resolve to a package", schemasDir, testDir)
+	}
+	return nil
+}
+
+// fetchedModule is the location of the source of a module the schemas depend on, or why it cannot be fetched.
+type fetchedModule struct {
+	loc module.SourceLoc
+	err error
+}
+
+func fetchModule(registry modconfig.Registry, mv module.Version) *fetchedModule {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	loc, err := registry.Fetch(ctx, mv)
+	return &fetchedModule{loc: loc, err: err}
+}
+
+// verifyPackage checks that the directory dir of fsys holds .cue files of the given package.
+func verifyPackage(fsys fs.FS, dir string, name string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.cue"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no CUE file in %s", dir)
+	}
+	packages := make(map[string]bool)
+	for _, f := range files {
+		data, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return err
+		}
+		file, err := parser.ParseFile(f, data, parser.PackageClauseOnly)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+		packages[file.PackageName()] = true
+	}
+	if !packages[name] {
+		return fmt.Errorf("no package %s in %s, found %s", name, dir, strings.Join(slices.Sorted(maps.Keys(packages)), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"cuelang.org/go/mod/module"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+// fakeRegistry serves the modules of its map, at the version they're keyed with.
+type fakeRegistry map[string]fstest.MapFS
+
+func (r fakeRegistry) Requirements(_ context.Context, _ module.Version) ([]module.Version, error) {
+	return nil, nil
+}
+
+func (r fakeRegistry) Fetch(_ context.Context, mv module.Version) (module.SourceLoc, error) {
+	fsys, ok := r[mv.String()]
+	if !ok {
+		return module.SourceLoc{}, errors.New("cannot do HTTP request")
+	}
+	return module.SourceLoc{FS: fsys, Dir: "."}, nil
+}
+
+func (r fakeRegistry) ModuleVersions(_ context.Context, _ string) ([]string, error) {
+	return nil, nil
+}
+
+func TestVerifyImports(t *testing.T) {
+	const moduleFile = `module: "github.com/perses/shared/cue@v0"
+language: version: "v0.15.0"
+deps: "github.com/perses/spec/cue@v0": {
+	v:       "v0.2.0"
+	default: true
+}
+`
+	spec := fstest.MapFS{
+		"cue.mod/module.cue":    {Data: []byte(`module: "github.com/perses/spec/cue@v0"`)},
+		"common/common.cue":     {Data: []byte("package common\n")},
+		"dashboard/panel.cue":   {Data: []byte("package dashboard\n")},
+		"dashboard/layout.cue":  {Data: []byte("package dashboard\n")},
+		"variable/variable.cue": {Data: []byte("package variable\n")},
+	}
+	tests := []struct {
+		name     string
+		imports  []string
+		registry fakeRegistry
+		wantErr  string
+	}{
+		{
+			name:     "packages of the pinned version",
+			imports:  []string{"github.com/perses/spec/cue/common", "github.com/perses/spec/cue/dashboard", "github.com/perses/shared/cue/common", "strings"},
+			registry: fakeRegistry{"github.com/perses/spec/cue@v0.2.0": spec},
+		},
+		{
+			name:     "package missing from the pinned version",
+			imports:  []string{"github.com/perses/spec/cue/datasource"},
+			registry: fakeRegistry{"github.com/perses/spec/cue@v0.2.0": spec},
+			wantErr:  "github.com/perses/spec/cue/datasource imported by cue/common/common.cue: not found in github.com/perses/spec/cue@v0.2.0: no CUE file in datasource",
+		},
+		{
+			name:     "package name mismatch",
+			imports:  []string{"github.com/perses/spec/cue/variable:vars"},
+			registry: fakeRegistry{"github.com/perses/spec/cue@v0.2.0": spec},
+			wantErr:  "no package vars in variable, found variable",
+		},
+		{
+			name:    "module not declared",
+			imports: []string{"github.com/perses/plugins/cue/prometheus"},
+			wantErr: "module not declared in cue/cue.mod/module.cue",
+		},
+		{
+			name:     "local package missing",
+			imports:  []string{"github.com/perses/shared/cue/missing"},
+			registry: fakeRegistry{},
+			wantErr:  "github.com/perses/shared/cue/missing imported by cue/common/common.cue: no CUE file in cue/missing",
+		},
+		{
+			name:    "unreachable registry",
+			imports: []string{"github.com/perses/spec/cue/common"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			var imports strings.Builder
+			for _, i := range test.imports {
+				imports.WriteString("import \"" + i + "\"\n")
+			}
+			testutil.WriteFiles(t, ".", map[string]string{
+				"cue/cue.mod/module.cue":     moduleFile,
+				"cue/common/common.cue":      "package common\n\n" + imports.String(),
+				"cue-test/common/common.cue": "package common\n",
+			})
+			err := verifyImports(test.registry)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
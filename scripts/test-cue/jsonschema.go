@@ -0,0 +1,114 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/encoding/jsonschema"
+	"cuelang.org/go/mod/modconfig"
+	"github.com/sirupsen/logrus"
+)
+
+// exportJSONSchemas converts each definition of the schema packages to a JSON Schema file,
+// written to <outputDir>/<package>/<definition>.json, or <outputDir>/<package>/<name>/<definition>.json
+// when the directory of the package holds several of them.
+func exportJSONSchemas(registry modconfig.Registry, outputDir string, dirsInScope []string, excluded []string) error {
+	ctx := cuecontext.New()
+
+	count := 0
+	err := forEachPackage(dirsInScope, excluded, func(packageDir string) error {
+		packages, err := loadPackages(ctx, registry, schemasDir, packageDir)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", filepath.Join(schemasDir, packageDir), err)
+		}
+		for _, p := range packages {
+			label := packageLabel(packageDir, p.name, len(packages))
+			packageOutputDir := filepath.Join(outputDir, packageDir)
+			if len(packages) > 1 {
+				packageOutputDir = filepath.Join(packageOutputDir, p.name)
+			}
+			iter, err := p.value.Fields(cue.Definitions(true))
+			if err != nil {
+				return err
+			}
+			for iter.Next() {
+				if !iter.Selector().IsDefinition() {
+					continue
+				}
+				definition := iter.Selector().String()
+				expr, err := jsonschema.Generate(iter.Value(), nil)
+				if err != nil {
+					return fmt.Errorf("failed to generate the JSON Schema of %s in %s: %w", definition, label, err)
+				}
+				data, err := ctx.BuildExpr(expr).MarshalJSON()
+				if err != nil {
+					return fmt.Errorf("failed to marshal the JSON Schema of %s in %s: %w", definition, label, err)
+				}
+				if err := writeJSON(filepath.Join(packageOutputDir, strings.TrimPrefix(definition, "#")+".json"), data); err != nil {
+					return err
+				}
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("✓ %d JSON Schema(s) written to %s", count, outputDir)
+	return nil
+}
+
+// forEachPackage calls fn with each schema package in scope, relative to cue/.
+func forEachPackage(dirsInScope []string, excluded []string, fn func(packageDir string) error) error {
+	for _, dirInScope := range dirsInScope {
+		packageDirs, err := findPackages(schemasDir, dirInScope)
+		if err != nil {
+			return fmt.Errorf("failed to find directories in %s/%s: %w", schemasDir, dirInScope, err)
+		}
+		for _, packageDir := range packageDirs {
+			if isUnder(packageDir, excluded) {
+				continue
+			}
+			if err := fn(packageDir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeJSON writes the JSON data indented to path, creating the parent directories if needed.
+func writeJSON(path string, data []byte) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil { //nolint: gosec
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, indented.Bytes(), 0644); err != nil { //nolint: gosec
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
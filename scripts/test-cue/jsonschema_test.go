@@ -0,0 +1,72 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestExportJSONSchemas(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantFiles []string
+	}{
+		{
+			name: "single package",
+			files: map[string]string{
+				"cue/common/format.cue": "package common\n\n#Format: {unit?: string}\n",
+				"cue/common/unit.cue":   "package common\n\n#Unit: string\n",
+			},
+			wantFiles: []string{"common/Format.json", "common/Unit.json"},
+		},
+		{
+			name: "several packages in a directory",
+			files: map[string]string{
+				"cue/common/format.cue": "package common\n\n#Format: {unit?: string}\n",
+				"cue/common/legacy.cue": "package legacy\n\n#Format: {unit?: string}\n",
+			},
+			wantFiles: []string{"common/common/Format.json", "common/legacy/Format.json"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			test.files["cue/cue.mod/module.cue"] = testModule
+			testutil.WriteFiles(t, ".", test.files)
+			if err := exportJSONSchemas(nil, "out", []string{"common"}, nil); err != nil {
+				t.Fatal(err)
+			}
+			var files []string
+			err := filepath.WalkDir("out", func(path string, entry os.DirEntry, err error) error {
+				if err == nil && !entry.IsDir() {
+					rel, _ := filepath.Rel("out", path)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				return err
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(files, test.wantFiles) {
+				t.Errorf("got files %q, expected %q", files, test.wantFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/token"
+)
+
+// kindPattern is the naming rule of the kind discriminators, e.g. `kind: "MergeSeries"`.
+var kindPattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// lintPackage checks the conventions of the schemas of a package, returning the violations in the `<file>:<line>: <message>` format:
+//   - every exported definition has a doc comment,
+//   - a field documented as deprecated carries a @deprecated attribute, so that tools can detect it,
+//   - a kind discriminator is PascalCase.
+func lintPackage(schemaDir string) ([]string, error) {
+	undocumented, err := undocumentedDefinitions(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+	var violations []string
+	for _, definition := range undocumented {
+		violations = append(violations, definition+" has no doc comment")
+	}
+
+	files, err := parseCueFiles(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		ast.Walk(file, func(node ast.Node) bool {
+			field, ok := node.(*ast.Field)
+			if !ok {
+				return true
+			}
+			name, _, _ := ast.LabelName(field.Label)
+			if isDocumentedDeprecated(field) && !hasAttribute(field, "deprecated") {
+				violations = append(violations, violation(field.Pos(), fmt.Sprintf("%s is documented as deprecated but has no @deprecated attribute", name)))
+			}
+			if name == "kind" {
+				if lit, ok := field.Value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					if kind, err := strconv.Unquote(lit.Value); err == nil && !kindPattern.MatchString(kind) {
+						violations = append(violations, violation(lit.Pos(), fmt.Sprintf("kind %q must be PascalCase", kind)))
+					}
+				}
+			}
+			return true
+		}, nil)
+	}
+	return violations, nil
+}
+
+func violation(pos token.Pos, message string) string {
+	return fmt.Sprintf("%s:%d: %s", pos.Filename(), pos.Line(), message)
+}
+
+// isDocumentedDeprecated returns true if the doc comment of the field states it's deprecated.
+func isDocumentedDeprecated(field *ast.Field) bool {
+	for _, cg := range ast.Comments(field) {
+		if cg.Doc && strings.Contains(strings.ToLower(cg.Text()), "deprecated") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAttribute(field *ast.Field, key string) bool {
+	for _, attr := range field.Attrs {
+		if k, _ := attr.Split(); k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue/ast"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/mod/modconfig"
+	"cuelang.org/go/mod/modfile"
+	"github.com/sirupsen/logrus"
+)
+
+// resolveTimeout is the time given to the registry to resolve each dependency of the module.
+const resolveTimeout = 30 * time.Second
+
+// modulePattern extracts the major version from the module path, e.g. `module: "github.com/perses/shared/cue@v0"`.
+var modulePattern = regexp.MustCompile(`(?m)^module:\s*"[^"@]+@(v\d+)"`)
+
+// verifyModuleVersion checks that the major version declared in cue.mod/module.cue matches the release version.
+func verifyModuleVersion(version string) error {
+	modulePath := filepath.Join(schemasDir, "cue.mod", "module.cue")
+	data, err := os.ReadFile(modulePath) //nolint: gosec
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", modulePath, err)
+	}
+	matches := modulePattern.FindSubmatch(data)
+	if matches == nil {
+		logrus.Infof("No major version declared in %s, skipping module version check", modulePath)
+		return nil
+	}
+	moduleMajor := string(matches[1])
+	releaseMajor := "v" + strings.SplitN(version, ".", 2)[0]
+	if moduleMajor != releaseMajor {
+		return fmt.Errorf("module version %s in %s doesn't match the release %s (expected %s)", moduleMajor, modulePath, version, releaseMajor)
+	}
+	logrus.Infof("✓ Module version %s matches the release %s", moduleMajor, version)
+	return nil
+}
+
+// verifyModuleReachable checks that the CUE module used to vet the packages (the one of schemasDir) is the one enclosing packageDir.
+// Otherwise, the imports of the package would not be resolved against the expected module.
+func verifyModuleReachable(packageDir string) error {
+	moduleRoot, err := filepath.Abs(schemasDir)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(packageDir)
+	if err != nil {
+		return err
+	}
+	for {
+		if info, statErr := os.Stat(filepath.Join(dir, "cue.mod")); statErr == nil && info.IsDir() {
+			if dir != moduleRoot {
+				return fmt.Errorf("package %s belongs to the CUE module %s instead of %s", packageDir, dir, moduleRoot)
+			}
+			return nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no cue.mod found for package %s, it must be located under %s", packageDir, schemasDir)
+		}
+		dir = parent
+	}
+}
+
+// fileImports returns the import paths of the .cue files under the given directories, the cue.mod directories excluded.
+func fileImports(dirs ...string) (map[string][]string, error) {
+	imports := make(map[string][]string)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == "cue.mod" {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || filepath.Ext(path) != ".cue" {
+				return nil
+			}
+			file, err := parser.ParseFile(path, nil, parser.ImportsOnly)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			for _, spec := range file.Imports {
+				importPath, err := strconv.Unquote(spec.Path.Value)
+				if err != nil {
+					return fmt.Errorf("invalid import %s in %s: %w", spec.Path.Value, path, err)
+				}
+				imports[importPath] = append(imports[importPath], path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return imports, nil
+}
+
+// readModuleFile parses cue.mod/module.cue.
+func readModuleFile() (*modfile.File, error) {
+	modulePath := filepath.Join(schemasDir, "cue.mod", "module.cue")
+	data, err := os.ReadFile(modulePath) //nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", modulePath, err)
+	}
+	moduleFile, err := modfile.Parse(data, modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", modulePath, err)
+	}
+	return moduleFile, nil
+}
+
+// verifyModuleTidy checks that cue.mod/module.cue declares exactly the modules imported by the schemas and their tests,
+// and that the registry is able to resolve each of them.
+func verifyModuleTidy(registry modconfig.Registry) error {
+	modulePath := filepath.Join(schemasDir, "cue.mod", "module.cue")
+	moduleFile, err := readModuleFile()
+	if err != nil {
+		return err
+	}
+	imports, err := fileImports(schemasDir, testDir)
+	if err != nil {
+		return err
+	}
+
+	var issues []string
+	used := make(map[string]bool)
+	for importPath, files := range imports {
+		importedPath := ast.ParseImportPath(importPath).Path
+		// the standard library packages don't have a domain name as first element
+		if !strings.Contains(strings.Split(importedPath, "/")[0], ".") {
+			continue
+		}
+		if importedPath == moduleFile.ModulePath() || strings.HasPrefix(importedPath, moduleFile.ModulePath()+"/") {
+			continue
+		}
+		mv, ok := moduleFile.ModuleForImportPath(importPath)
+		if !ok {
+			issues = append(issues, fmt.Sprintf("%s imported by %s is not declared in %s", importPath, strings.Join(files, ", "), modulePath))
+			continue
+		}
+		used[mv.Path()] = true
+	}
+
+	for _, mv := range moduleFile.DepVersions() {
+		if !used[mv.Path()] {
+			issues = append(issues, fmt.Sprintf("%s is declared in %s but never imported", mv, modulePath))
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		_, err := registry.Requirements(ctx, mv)
+		cancel()
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s cannot be resolved: %v", mv, err))
+		}
+	}
+
+	if len(issues) > 0 {
+		slices.Sort(issues)
+		return fmt.Errorf("module %s is not tidy:\n  %s", modulePath, strings.Join(issues, "\n  "))
+	}
+	logrus.Infof("✓ Module %s is tidy", modulePath)
+	return nil
+}
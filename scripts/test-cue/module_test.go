@@ -0,0 +1,108 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestVerifyModuleVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		module  string
+		version string
+		wantErr bool
+	}{
+		{
+			name:    "matching major",
+			module:  `module: "github.com/perses/shared/cue@v0"`,
+			version: "0.53.0",
+		},
+		{
+			name:    "matching new major",
+			module:  `module: "github.com/perses/shared/cue@v1"`,
+			version: "1.0.0-rc.0",
+		},
+		{
+			name:    "module left behind by a new major",
+			module:  `module: "github.com/perses/shared/cue@v0"`,
+			version: "1.0.0",
+			wantErr: true,
+		},
+		{
+			name:    "no major declared",
+			module:  `module: "github.com/perses/shared/cue"`,
+			version: "1.0.0",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"cue/cue.mod/module.cue": test.module + "\nlanguage: version: \"v0.15.0\"\n",
+			})
+			if err := verifyModuleVersion(test.version); (err != nil) != test.wantErr {
+				t.Errorf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyModuleReachable(t *testing.T) {
+	tests := []struct {
+		name       string
+		packageDir string
+		wantErr    string
+	}{
+		{
+			name:       "package inside the module",
+			packageDir: "cue/common",
+		},
+		{
+			name:       "package of a nested module",
+			packageDir: "cue/legacy/panels",
+			wantErr:    "belongs to the CUE module",
+		},
+		{
+			name:       "package outside the module",
+			packageDir: "schemas/common",
+			wantErr:    "no cue.mod found for package schemas/common",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"cue/cue.mod/module.cue":        "module: \"github.com/perses/shared/cue@v0\"\n",
+				"cue/common/format.cue":         "package common\n",
+				"cue/legacy/cue.mod/module.cue": "module: \"github.com/perses/legacy@v0\"\n",
+				"cue/legacy/panels/panel.cue":   "package panels\n",
+				"schemas/common/format.cue":     "package common\n",
+			})
+			err := verifyModuleReachable(test.packageDir)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+		})
+	}
+}
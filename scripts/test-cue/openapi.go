@@ -0,0 +1,137 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/encoding/jsonschema"
+	"cuelang.org/go/mod/modconfig"
+	"github.com/perses/perses/scripts/pkg/npm"
+	"github.com/sirupsen/logrus"
+)
+
+const openAPIVersion = "3.1.0"
+
+// componentName converts the name of a definition, or of a JSON Schema $defs entry (e.g. `#percentFormat.unit`),
+// to a valid OpenAPI component name.
+func componentName(name string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(name, "#"), "#", "")
+}
+
+// toComponents moves the $defs of the JSON Schema to the component schemas, and rewrites the references accordingly.
+func toComponents(schema any, components map[string]any) any {
+	switch s := schema.(type) {
+	case map[string]any:
+		if defs, ok := s["$defs"].(map[string]any); ok {
+			for name, def := range defs {
+				components[componentName(name)] = toComponents(def, components)
+			}
+			delete(s, "$defs")
+		}
+		delete(s, "$schema")
+		for key, value := range s {
+			if ref, ok := value.(string); ok && key == "$ref" && strings.HasPrefix(ref, "#/$defs/") {
+				s[key] = "#/components/schemas/" + componentName(strings.TrimPrefix(ref, "#/$defs/"))
+				continue
+			}
+			s[key] = toComponents(value, components)
+		}
+	case []any:
+		for i, value := range s {
+			s[i] = toComponents(value, components)
+		}
+	}
+	return schema
+}
+
+// exportOpenAPI converts each schema package to an OpenAPI document holding its definitions as component schemas,
+// written to <outputDir>/<package>/openapi.json, or <outputDir>/<package>/<name>/openapi.json
+// when the directory of the package holds several of them.
+// As of OpenAPI 3.1, the component schemas are JSON Schemas, so they're generated the same way as the -jsonschema mode.
+func exportOpenAPI(registry modconfig.Registry, outputDir string, dirsInScope []string, excluded []string) error {
+	ctx := cuecontext.New()
+	version := npm.MustGetVersion(".")
+
+	count := 0
+	err := forEachPackage(dirsInScope, excluded, func(packageDir string) error {
+		packages, err := loadPackages(ctx, registry, schemasDir, packageDir)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", filepath.Join(schemasDir, packageDir), err)
+		}
+		for _, p := range packages {
+			label := packageLabel(packageDir, p.name, len(packages))
+			packageOutputDir := filepath.Join(outputDir, packageDir)
+			if len(packages) > 1 {
+				packageOutputDir = filepath.Join(packageOutputDir, p.name)
+			}
+			iter, err := p.value.Fields(cue.Definitions(true))
+			if err != nil {
+				return err
+			}
+			components := make(map[string]any)
+			for iter.Next() {
+				if !iter.Selector().IsDefinition() {
+					continue
+				}
+				definition := iter.Selector().String()
+				expr, err := jsonschema.Generate(iter.Value(), nil)
+				if err != nil {
+					return fmt.Errorf("failed to generate the JSON Schema of %s in %s: %w", definition, label, err)
+				}
+				data, err := ctx.BuildExpr(expr).MarshalJSON()
+				if err != nil {
+					return fmt.Errorf("failed to marshal the JSON Schema of %s in %s: %w", definition, label, err)
+				}
+				var schema any
+				if err := json.Unmarshal(data, &schema); err != nil {
+					return err
+				}
+				components[componentName(definition)] = toComponents(schema, components)
+			}
+			if len(components) == 0 {
+				continue
+			}
+
+			data, err := json.Marshal(map[string]any{
+				"openapi": openAPIVersion,
+				"info": map[string]string{
+					"title":   packageLabel(path.Join("github.com/perses/shared/cue", filepath.ToSlash(packageDir)), p.name, len(packages)),
+					"version": version,
+				},
+				"paths":      map[string]any{},
+				"components": map[string]any{"schemas": components},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal the OpenAPI document of %s: %w", label, err)
+			}
+			if err := writeJSON(filepath.Join(packageOutputDir, "openapi.json"), data); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logrus.Infof("✓ %d OpenAPI document(s) written to %s", count, outputDir)
+	return nil
+}
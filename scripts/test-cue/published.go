@@ -0,0 +1,115 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"cuelang.org/go/mod/modconfig"
+	"cuelang.org/go/mod/module"
+	"github.com/perses/shared/scripts/git"
+	"github.com/sirupsen/logrus"
+)
+
+// verifyPublishedModule checks that the CUE module published to the registry for the given tag holds the schemas of cue/
+// as of this tag, i.e. that the release didn't skip publishing the updated schemas.
+// Only the .cue files are compared, the cue.mod directory being rewritten when publishing.
+func verifyPublishedModule(registry modconfig.Registry, tag string) error {
+	moduleFile, err := readModuleFile()
+	if err != nil {
+		return err
+	}
+	mv, err := module.NewVersion(moduleFile.QualifiedModule(), tag)
+	if err != nil {
+		return fmt.Errorf("invalid version %s for module %s: %w", tag, moduleFile.QualifiedModule(), err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	loc, err := registry.Fetch(ctx, mv)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the published module %s: %w", mv, err)
+	}
+	published, err := cueFiles(loc.FS, loc.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read the published module %s: %w", mv, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "test-cue-published-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	if err := git.ExtractTree(tag, schemasDir, tmpDir); err != nil {
+		return err
+	}
+	tagged, err := cueFiles(os.DirFS(filepath.Join(tmpDir, schemasDir)), ".")
+	if err != nil {
+		return fmt.Errorf("failed to read %s at %s: %w", schemasDir, tag, err)
+	}
+
+	var issues []string
+	for _, file := range slices.Sorted(maps.Keys(tagged)) {
+		content, ok := published[file]
+		switch {
+		case !ok:
+			issues = append(issues, fmt.Sprintf("%s is not published", file))
+		case !bytes.Equal(content, tagged[file]):
+			issues = append(issues, fmt.Sprintf("%s differs from the published one", file))
+		}
+	}
+	for _, file := range slices.Sorted(maps.Keys(published)) {
+		if _, ok := tagged[file]; !ok {
+			issues = append(issues, fmt.Sprintf("%s is published but doesn't exist at %s", file, tag))
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("the published module %s doesn't match %s at %s, was it published?\n  %s", mv, schemasDir, tag, strings.Join(issues, "\n  "))
+	}
+	logrus.Infof("✓ Published module %s matches %s at %s (%d files)", mv, schemasDir, tag, len(tagged))
+	return nil
+}
+
+// cueFiles reads the .cue files under root in fsys, the cue.mod directory excluded, by path relative to root.
+func cueFiles(fsys fs.FS, root string) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "cue.mod" {
+			return fs.SkipDir
+		}
+		if d.IsDir() || path.Ext(p) != ".cue" {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		files[rel] = content
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,86 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+	colorBlue  = "\033[34m"
+)
+
+// colorOutput enables the colors of the rendered errors when they're printed on a terminal, unless NO_COLOR is set.
+var colorOutput = isTerminal(os.Stderr) && os.Getenv("NO_COLOR") == ""
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func colorize(color string, s string) string {
+	if !colorOutput {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// renderDiagnostics prints each diagnostic with an excerpt of the source at each of its positions: the first one is where
+// the error was detected, the next ones are usually the constraints the value conflicts with.
+func renderDiagnostics(w io.Writer, diagnostics []diagnostic) {
+	sources := make(map[string][]string)
+	for _, d := range diagnostics {
+		fmt.Fprintf(w, "%s %s\n", colorize(colorBold+colorRed, "error:"), colorize(colorBold, d.Message))
+		for _, p := range d.Positions {
+			fmt.Fprintf(w, "  %s %s:%d:%d\n", colorize(colorBlue, "-->"), p.File, p.Line, p.Column)
+			lines, ok := sources[p.File]
+			if !ok {
+				lines = readLines(p.File)
+				sources[p.File] = lines
+			}
+			if p.Line < 1 || p.Line > len(lines) {
+				continue
+			}
+			gutter := fmt.Sprintf("%d", p.Line)
+			line := strings.ReplaceAll(lines[p.Line-1], "\t", " ")
+			fmt.Fprintf(w, "  %s %s\n", colorize(colorBlue, gutter+" |"), line)
+			if p.Column >= 1 {
+				caret := strings.Repeat(" ", min(p.Column-1, len(line))) + "^"
+				fmt.Fprintf(w, "  %s %s\n", colorize(colorBlue, strings.Repeat(" ", len(gutter))+" |"), colorize(colorRed, caret))
+			}
+		}
+	}
+}
+
+// readLines returns the lines of the given file, or nil if it cannot be read, the excerpts being then omitted.
+func readLines(file string) []string {
+	f, err := os.Open(file) //nolint: gosec
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
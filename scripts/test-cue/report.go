@@ -0,0 +1,97 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	statusValidated = "validated"
+	statusSkipped   = "skipped"
+	statusFailed    = "failed"
+)
+
+// packageStatus is the outcome of a package in the machine-readable report.
+type packageStatus struct {
+	Package string `json:"package"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	// Duration is the validation time of the package in seconds, zero for a skipped package.
+	Duration float64 `json:"durationSeconds,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    float64       `xml:"time,attr"`
+	Skipped *junitSkipped `xml:"skipped,omitempty"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// writeReport writes the status of each package to path, as JUnit XML if the file has the .xml extension, as JSON otherwise.
+func writeReport(path string, statuses []packageStatus) error {
+	var data []byte
+	var err error
+	if filepath.Ext(path) == ".xml" {
+		data, err = junitReport(statuses)
+	} else {
+		data, err = json.MarshalIndent(statuses, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644) //nolint: gosec
+}
+
+func junitReport(statuses []packageStatus) ([]byte, error) {
+	suite := junitTestSuite{Name: "test-cue", Tests: len(statuses)}
+	for _, s := range statuses {
+		testCase := junitTestCase{Name: s.Package, Time: s.Duration}
+		switch s.Status {
+		case statusSkipped:
+			testCase.Skipped = &junitSkipped{Message: s.Message}
+			suite.Skipped++
+		case statusFailed:
+			testCase.Failure = &junitFailure{Message: s.Message}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal the JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,162 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	cueerrors "cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/token"
+)
+
+type position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// diagnostic is a CUE validation error, with the positions involved.
+type diagnostic struct {
+	Message   string
+	Positions []position
+}
+
+// toPosition converts a CUE position, making its file relative to the repository root.
+func toPosition(pos token.Pos) position {
+	wd, _ := os.Getwd()
+	file := pos.Filename()
+	if rel, relErr := filepath.Rel(wd, file); relErr == nil && filepath.IsAbs(file) {
+		file = rel
+	}
+	return position{File: filepath.ToSlash(file), Line: pos.Line(), Column: pos.Column()}
+}
+
+// toDiagnostics converts the CUE errors to diagnostics. Positions are made relative to the repository root.
+func toDiagnostics(err error) []diagnostic {
+	var diagnostics []diagnostic
+	for _, e := range cueerrors.Errors(err) {
+		// String keeps the path of the error and the messages of the errors it wraps, e.g. why an import failed
+		d := diagnostic{Message: cueerrors.String(e)}
+		for _, pos := range cueerrors.Positions(e) {
+			d.Positions = append(d.Positions, toPosition(pos))
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifReport struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+const vetRuleID = "cue-vet"
+
+func toSarifLocations(positions []position) []sarifLocation {
+	var locations []sarifLocation
+	for _, p := range positions {
+		locations = append(locations, sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: p.File},
+			Region:           sarifRegion{StartLine: p.Line, StartColumn: p.Column},
+		}})
+	}
+	return locations
+}
+
+// writeSarif writes the diagnostics as a SARIF report, so that they can be uploaded as GitHub code-scanning alerts.
+// The first position of a diagnostic is its location, the others are reported as related locations.
+func writeSarif(w io.Writer, diagnostics []diagnostic) error {
+	results := []sarifResult{}
+	for _, d := range diagnostics {
+		result := sarifResult{
+			RuleID:  vetRuleID,
+			Level:   "error",
+			Message: sarifMessage{Text: d.Message},
+		}
+		if len(d.Positions) > 0 {
+			result.Locations = toSarifLocations(d.Positions[:1])
+			result.RelatedLocations = toSarifLocations(d.Positions[1:])
+		}
+		results = append(results, result)
+	}
+	report := sarifReport{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "test-cue",
+				InformationURI: "https://github.com/perses/shared",
+				Rules:          []sarifRule{{ID: vetRuleID, ShortDescription: sarifMessage{Text: "CUE schema validation failure"}}},
+			}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
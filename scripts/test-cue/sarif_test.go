@@ -0,0 +1,112 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+const testModule = `module: "github.com/perses/test@v0"
+language: version: "v0.15.0"
+`
+
+func TestReportErrorsUnresolvedImport(t *testing.T) {
+	t.Chdir(t.TempDir())
+	testutil.WriteFiles(t, ".", map[string]string{
+		"cue/cue.mod/module.cue": testModule,
+		"cue/foo/foo.cue":        "package foo\n\nimport \"github.com/perses/test/missing\"\n\n#Foo: missing.#Bar\n",
+	})
+
+	_, err := loadFiles(cuecontext.New(), nil, []string{"cue/foo/foo.cue"})
+	if err == nil {
+		t.Fatal("expected the unresolved import to fail the load")
+	}
+	var output bytes.Buffer
+	diagnostics := reportErrors(&output, err)
+	if len(diagnostics) == 0 {
+		t.Fatal("expected at least one diagnostic")
+	}
+	if !strings.Contains(diagnostics[0].Message, "github.com/perses/test/missing") {
+		t.Errorf("the message %q doesn't tell which import failed and why", diagnostics[0].Message)
+	}
+	if !strings.Contains(output.String(), diagnostics[0].Message) {
+		t.Errorf("the rendered output %q doesn't include the message %q", output.String(), diagnostics[0].Message)
+	}
+}
+
+func TestWriteSarif(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	value := cuecontext.New().CompileString("package common\n\n#Format: {decimalPlaces: int}\n\nformat: #Format & {decimalPlaces: \"two\"}\n",
+		cue.Filename(filepath.Join(dir, "cue", "common", "format.cue")))
+	err := value.Validate()
+	if err == nil {
+		t.Fatal("expected the conflicting decimal places to fail the validation")
+	}
+
+	var output bytes.Buffer
+	if err := writeSarif(&output, toDiagnostics(err)); err != nil {
+		t.Fatal(err)
+	}
+	var report sarifReport
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("invalid SARIF report: %v\n%s", err, output.String())
+	}
+	if report.Version != "2.1.0" || len(report.Runs) != 1 {
+		t.Fatalf("expected a single SARIF 2.1.0 run, got version %s with %d run(s)", report.Version, len(report.Runs))
+	}
+	run := report.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != vetRuleID {
+		t.Errorf("expected the single rule %s, got %+v", vetRuleID, run.Tool.Driver.Rules)
+	}
+	if len(run.Results) == 0 {
+		t.Fatal("expected at least one result")
+	}
+	result := run.Results[0]
+	if result.RuleID != vetRuleID || result.Level != "error" {
+		t.Errorf("got rule %s at level %s, expected %s at level error", result.RuleID, result.Level, vetRuleID)
+	}
+	if !strings.Contains(result.Message.Text, "format.decimalPlaces") {
+		t.Errorf("the message %q doesn't tell which field is invalid", result.Message.Text)
+	}
+	if len(result.Locations) != 1 {
+		t.Fatalf("expected exactly one location, got %+v", result.Locations)
+	}
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "cue/common/format.cue" {
+		t.Errorf("got location %s, expected the path relative to the repository root", location.ArtifactLocation.URI)
+	}
+	if location.Region.StartLine != 3 && location.Region.StartLine != 5 {
+		t.Errorf("got line %d, expected the line of the decimalPlaces definition or of its value", location.Region.StartLine)
+	}
+}
+
+func TestWriteSarifNoDiagnostic(t *testing.T) {
+	var output bytes.Buffer
+	if err := writeSarif(&output, nil); err != nil {
+		t.Fatal(err)
+	}
+	// code scanning expects an empty list of results rather than null to close the previous alerts
+	if !strings.Contains(output.String(), `"results": []`) {
+		t.Errorf("expected an empty list of results:\n%s", output.String())
+	}
+}
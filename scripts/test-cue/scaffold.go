@@ -0,0 +1,107 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"cuelang.org/go/cue/ast"
+	"github.com/sirupsen/logrus"
+)
+
+const licenseHeader = `// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+`
+
+// scaffoldTests creates a test directory with a stub test file for each schema package that has none.
+// The stub belongs to the same package as the schemas, as vetPackage requires, and imports the schema package,
+// so that the tests reference its definitions the same way the users of the module do, e.g. `common.#Format`.
+// A directory holding several packages gets a stub per package, named after it.
+func scaffoldTests(dirsInScope []string, excluded []string) error {
+	moduleFile, err := readModuleFile()
+	if err != nil {
+		return err
+	}
+	for _, dirInScope := range dirsInScope {
+		packageDirs, err := findPackages(schemasDir, dirInScope)
+		if err != nil {
+			return fmt.Errorf("failed to find directories in %s/%s: %w", schemasDir, dirInScope, err)
+		}
+		for _, packageDir := range packageDirs {
+			schemaDir := filepath.Join(schemasDir, packageDir)
+			testDir := filepath.Join(testDir, packageDir)
+			if isUnder(packageDir, excluded) {
+				continue
+			}
+			if _, err := os.Stat(testDir); err == nil {
+				continue
+			}
+			files, err := parseCueFiles(schemaDir)
+			if err != nil {
+				return err
+			}
+			packages := make(map[string][]*ast.File)
+			for _, file := range files {
+				// files without package clause can't be imported
+				if name := file.PackageName(); name != "" {
+					packages[name] = append(packages[name], file)
+				}
+			}
+			if len(packages) == 0 {
+				logrus.Debugf("Skipping %s: no CUE package", schemaDir)
+				continue
+			}
+			if err := os.MkdirAll(testDir, 0755); err != nil { //nolint: gosec
+				return fmt.Errorf("failed to create %s: %w", testDir, err)
+			}
+			for _, name := range slices.Sorted(maps.Keys(packages)) {
+				importPath := path.Join(moduleFile.ModulePath(), filepath.ToSlash(packageDir))
+				// the package name is implied by the import path only when it matches its last element
+				if name != path.Base(importPath) {
+					importPath += ":" + name
+				}
+				example := "#definition"
+				if definitions := topLevelDefinitions(packages[name]); len(definitions) > 0 {
+					example, _, _ = ast.LabelName(definitions[0].Label)
+				}
+				stub := fmt.Sprintf("%s\npackage %s\n\nimport %q\n\n// The test values are unified with the definitions of the schema package, e.g.:\n//\n// myValue: %s.%s & {\n// }\n_schemas: %s\n",
+					licenseHeader, name, importPath, name, example, name)
+				testFile := filepath.Join(testDir, filepath.Base(packageDir)+".cue")
+				if len(packages) > 1 {
+					testFile = filepath.Join(testDir, name+".cue")
+				}
+				if err := os.WriteFile(testFile, []byte(stub), 0644); err != nil { //nolint: gosec
+					return fmt.Errorf("failed to write %s: %w", testFile, err)
+				}
+				logrus.Infof("Created the test stub %s for package %s", testFile, packageLabel(schemaDir, name, len(packages)))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestScaffoldTests(t *testing.T) {
+	t.Chdir(t.TempDir())
+	testutil.WriteFiles(t, ".", map[string]string{
+		"cue/cue.mod/module.cue":     testModule,
+		"cue/common/format.cue":      "package common\n\n// #Format is the format of a value.\n#Format: {\n\tunit?: string\n}\n",
+		"cue/plugins/table.cue":      "package table\n\n// #Table is a table panel.\n#Table: {\n\tdensity?: string\n}\n",
+		"cue/plugins/timeseries.cue": "package timeseries\n\n// #TimeSeries is a time series panel.\n#TimeSeries: {\n\tlegend?: bool\n}\n",
+		"cue/tested/tested.cue":      "package tested\n\n// #Tested is tested.\n#Tested: string\n",
+		"cue-test/tested/tested.cue": "package tested\n\nvalue: #Tested & \"ok\"\n",
+	})
+	if err := scaffoldTests([]string{"common", "plugins", "tested"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	stubs := []struct {
+		file       string
+		wantImport string
+		wantRef    string
+	}{
+		{file: "cue-test/common/common.cue", wantImport: `import "github.com/perses/test/common"`, wantRef: "common.#Format"},
+		{file: "cue-test/plugins/table.cue", wantImport: `import "github.com/perses/test/plugins:table"`, wantRef: "table.#Table"},
+		{file: "cue-test/plugins/timeseries.cue", wantImport: `import "github.com/perses/test/plugins:timeseries"`, wantRef: "timeseries.#TimeSeries"},
+	}
+	for _, stub := range stubs {
+		content := testutil.ReadFile(t, stub.file)
+		if !strings.Contains(content, stub.wantImport) || !strings.Contains(content, stub.wantRef) {
+			t.Errorf("stub %s doesn't import the schema package with %s, or doesn't show %s:\n%s", stub.file, stub.wantImport, stub.wantRef, content)
+		}
+	}
+	if content := testutil.ReadFile(t, "cue-test/tested/tested.cue"); !strings.Contains(content, "value:") {
+		t.Errorf("existing test directory overwritten:\n%s", content)
+	}
+	if entries, err := os.ReadDir("cue-test/tested"); err != nil || len(entries) != 1 {
+		t.Errorf("got entries %v (%v) in the existing test directory, expected only its test file", entries, err)
+	}
+
+	// the stubs are valid tests of their package
+	for _, packageDir := range []string{"common", "plugins"} {
+		if _, err := vetPackage(io.Discard, nil, filepath.Join(schemasDir, packageDir), filepath.Join(testDir, packageDir), false); err != nil {
+			t.Errorf("stubs of %s not valid: %v", packageDir, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// slowestCount is the number of packages listed in the summary of the validation, the slowest first.
+var slowestCount int
+
+// logSummary logs the total validation time of the packages, compared to the wall time of the run, and the slowest packages.
+func logSummary(results []*packageResult, wallTime time.Duration) {
+	if len(results) == 0 {
+		return
+	}
+	var total time.Duration
+	for _, result := range results {
+		total += result.duration
+	}
+	logrus.Infof("CUE validation took %s for %d package(s), %s of validation in total, %s on average",
+		wallTime.Round(time.Millisecond), len(results), total.Round(time.Millisecond), (total / time.Duration(len(results))).Round(time.Millisecond))
+	if slowestCount <= 0 {
+		return
+	}
+	sorted := slices.SortedStableFunc(slices.Values(results), func(a, b *packageResult) int {
+		return cmp.Compare(b.duration, a.duration)
+	})
+	var builder strings.Builder
+	for _, result := range sorted[:min(slowestCount, len(sorted))] {
+		fmt.Fprintf(&builder, "\n  %-50s %s", result.schemaDir, result.duration.Round(time.Millisecond))
+	}
+	logrus.Infof("Slowest package(s):%s", builder.String())
+}
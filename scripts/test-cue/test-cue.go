@@ -14,11 +14,27 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/load"
+	"cuelang.org/go/cue/parser"
+	"cuelang.org/go/mod/modconfig"
+	"github.com/perses/shared/scripts/config"
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/parallel"
+	"github.com/perses/shared/scripts/tag"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,10 +47,36 @@ const (
 	testDir    = "cue-test"
 )
 
-// dirsInScope specifies which subdirectories under cue/ to validate
-var dirsInScope = []string{"common"}
+// excludedDirs lists the directories under cue/ that are not validated, e.g. work-in-progress schemas.
+// A directory excludes all its subdirectories.
+var excludedDirs []string
 
-// NB: this function assume 1 dirInScope = 1 package. CUE allows multiple packages per dirInScope, but this is not used here.
+// discoverDirs returns all the subdirectories of basePath containing schemas, i.e. all of them except cue.mod.
+func discoverDirs(basePath string) ([]string, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != "cue.mod" {
+			dirs = append(dirs, entry.Name())
+		}
+	}
+	return dirs, nil
+}
+
+// isUnder returns true if the package directory (relative to cue/) is one of the given directories, or is under one of them.
+func isUnder(packageDir string, dirs []string) bool {
+	for _, dir := range dirs {
+		if packageDir == dir || strings.HasPrefix(packageDir, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// NB: a directory can hold several CUE packages, they're told apart by vetPackage.
 func findPackages(basePath string, dirInScope string) ([]string, error) {
 	var packages []string
 	dirPath := filepath.Join(basePath, dirInScope)
@@ -61,66 +103,282 @@ func findPackages(basePath string, dirInScope string) ([]string, error) {
 	return packages, err
 }
 
+// invalidSuffix is the suffix of the test files holding data the schemas must reject.
+// Each of them is vetted on its own against the schemas, and the validation is expected to fail.
+const invalidSuffix = "_invalid.cue"
+
+// packageFiles groups the given .cue files by the name of their package clause, the package names being returned sorted.
+func packageFiles(files []string) (map[string][]string, []string, error) {
+	packages := make(map[string][]string)
+	for _, f := range files {
+		file, err := parser.ParseFile(f, nil, parser.PackageClauseOnly)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", f, err)
+		}
+		packages[file.PackageName()] = append(packages[file.PackageName()], f)
+	}
+	return packages, slices.Sorted(maps.Keys(packages)), nil
+}
+
 // vetPackage validates CUE files in schemaDir against test files in testDir.
-// It collects all .cue files from both directories and runs `cue vet` on them together,
-// allowing CUE to merge files in the same package and resolve imports properly.
-// The command runs from schemasDir to ensure cue.mod/module.cue is accessible for imports.
-func vetPackage(schemaDir, testDir string) error {
+// A directory can hold several CUE packages: the files are grouped by package, and the test files of each package
+// are vetted against the schema files of the same package, see vetPackageFiles.
+// The JSON and YAML data files of testDir are then vetted against the schemas, see vetDataFiles.
+// The validation errors are returned as diagnostics, in addition to being printed to w.
+// When concrete is true, the test files must also be fully concrete, like with `cue vet -c`.
+func vetPackage(w io.Writer, registry modconfig.Registry, schemaDir, testDir string, concrete bool) ([]diagnostic, error) {
+	// a CUE context is not safe for concurrent use, each package gets its own
+	ctx := cuecontext.New()
 	logrus.Debugf("Validating package %s against %s", schemaDir, testDir)
 
 	// Get list of all .cue files in both directories
 	schemaFiles, err := filepath.Glob(filepath.Join(schemaDir, "*.cue"))
 	if err != nil {
-		return fmt.Errorf("failed to glob schema files: %w", err)
+		return nil, fmt.Errorf("failed to glob schema files: %w", err)
 	}
 	testFiles, err := filepath.Glob(filepath.Join(testDir, "*.cue"))
 	if err != nil {
-		return fmt.Errorf("failed to glob test files: %w", err)
+		return nil, fmt.Errorf("failed to glob test files: %w", err)
+	}
+	schemaPackages, packageNames, err := packageFiles(schemaFiles)
+	if err != nil {
+		return nil, err
+	}
+	testPackages, _, err := packageFiles(testFiles)
+	if err != nil {
+		return nil, err
+	}
+	for name, files := range testPackages {
+		if _, ok := schemaPackages[name]; !ok {
+			return nil, fmt.Errorf("test file(s) %s of package %s have no schema package %s in %s", strings.Join(files, ", "), name, name, schemaDir)
+		}
 	}
 
-	// Build command args with paths relative to schemasDir (cue/)
-	args := []string{"vet"}
-	for _, f := range schemaFiles {
-		rel, err := filepath.Rel(schemasDir, f)
+	var schemas []cue.Value
+	for _, name := range packageNames {
+		label := schemaDir
+		if len(packageNames) > 1 {
+			label = fmt.Sprintf("%s:%s", schemaDir, name)
+		}
+		if diagnostics, err := vetPackageFiles(w, ctx, registry, label, schemaPackages[name], testPackages[name], concrete); err != nil {
+			return diagnostics, err
+		}
+		value, err := loadFiles(ctx, registry, schemaPackages[name])
 		if err != nil {
-			return fmt.Errorf("failed to get relative path for %s: %w", f, err)
+			return reportErrors(w, err), fmt.Errorf("failed to load %s", label)
 		}
-		args = append(args, rel)
+		schemas = append(schemas, value)
+	}
+
+	allFiles, err := filepath.Glob(filepath.Join(testDir, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob data files: %w", err)
 	}
+	dataFiles := slices.DeleteFunc(allFiles, func(f string) bool { return !isDataFile(f) })
+	if len(dataFiles) == 0 {
+		return nil, nil
+	}
+	return vetDataFiles(w, ctx, schemas, dataFiles)
+}
+
+// vetPackageFiles validates the schema files of a package against its test files.
+// It loads all the files as a single instance, allowing CUE to merge files in the same package
+// and resolve imports properly, then validates it the way `cue vet` does.
+// The test files ending with _invalid.cue are excluded, and each of them must instead be rejected by the schemas.
+// The test files ending with _cases.cue are excluded as well, their cases being vetted one by one, see vetCases.
+func vetPackageFiles(w io.Writer, ctx *cue.Context, registry modconfig.Registry, label string, schemaFiles []string, testFiles []string, concrete bool) ([]diagnostic, error) {
+	var validFiles, invalidFiles, casesFiles []string
 	for _, f := range testFiles {
-		// testFiles are in ../cue-test relative to schemasDir
+		switch {
+		case strings.HasSuffix(f, invalidSuffix):
+			invalidFiles = append(invalidFiles, f)
+		case strings.HasSuffix(f, casesSuffix):
+			casesFiles = append(casesFiles, f)
+		default:
+			validFiles = append(validFiles, f)
+		}
+	}
+
+	vetErr, err := vetFiles(ctx, registry, slices.Concat(schemaFiles, validFiles), concrete)
+	if err != nil {
+		return reportErrors(w, err), fmt.Errorf("failed to load %s", label)
+	}
+	if vetErr != nil {
+		return reportErrors(w, vetErr), fmt.Errorf("failed to validate %s", label)
+	}
+
+	diagnostics, err := checkGoldens(w, ctx, registry, schemaFiles, validFiles)
+	if err != nil {
+		return diagnostics, err
+	}
+
+	for _, f := range invalidFiles {
+		vetErr, err := vetFiles(ctx, registry, append(slices.Clone(schemaFiles), f), concrete)
+		if err != nil {
+			return reportErrors(w, err), fmt.Errorf("failed to load %s with %s", label, f)
+		}
+		if vetErr == nil {
+			logrus.Errorf("%s is expected to be rejected by the schemas of %s, but it's valid", f, label)
+			diagnostics = append(diagnostics, diagnostic{
+				Message:   "expected to be rejected by the schemas, but it's valid",
+				Positions: []position{{File: filepath.ToSlash(f), Line: 1, Column: 1}},
+			})
+			continue
+		}
+		logrus.Debugf("%s rejected as expected: %v", f, vetErr)
+	}
+	if len(diagnostics) > 0 {
+		return diagnostics, fmt.Errorf("%d invalid test file(s) accepted by %s", len(diagnostics), label)
+	}
+
+	for _, f := range casesFiles {
+		if diagnostics, err := vetCases(w, ctx, registry, label, schemaFiles, f); err != nil {
+			return diagnostics, err
+		}
+	}
+	return nil, nil
+}
+
+// vetFiles loads the given files as a single instance and validates it.
+// It returns the validation error of the instance, or an error if the files cannot be loaded, e.g. when an import is not found.
+// When concrete is true, the regular fields of the instance must also have a concrete value, e.g. no required field left unset.
+func vetFiles(ctx *cue.Context, registry modconfig.Registry, files []string, concrete bool) (vetErr error, err error) {
+	value, err := loadFiles(ctx, registry, files)
+	if err != nil {
+		return nil, err
+	}
+	if err := value.Err(); err != nil {
+		return err, nil
+	}
+	if err := value.Validate(cue.Attributes(true), cue.Definitions(true), cue.Hidden(true)); err != nil || !concrete {
+		return err, nil
+	}
+	// the definitions are schemas, only the data has to be concrete
+	return value.Validate(cue.Concrete(true)), nil
+}
+
+// loadFiles loads the given files as a single instance and builds it.
+// It returns an error if the files cannot be loaded, e.g. when an import is not found.
+func loadFiles(ctx *cue.Context, registry modconfig.Registry, files []string) (cue.Value, error) {
+	// Build the list of files with paths relative to schemasDir (cue/), the test files being in ../cue-test
+	args := make([]string, 0, len(files))
+	for _, f := range files {
 		rel, err := filepath.Rel(schemasDir, f)
 		if err != nil {
-			return fmt.Errorf("failed to get relative path for %s: %w", f, err)
+			return cue.Value{}, fmt.Errorf("failed to get relative path for %s: %w", f, err)
 		}
 		args = append(args, rel)
 	}
 
-	cmd := exec.Command("cue", args...)
-	cmd.Dir = schemasDir
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to validate %s: %w", schemaDir, err)
+	instances := load.Instances(args, &load.Config{Dir: schemasDir, Registry: registry})
+	if len(instances) != 1 {
+		return cue.Value{}, fmt.Errorf("expected a single instance, got %d", len(instances))
+	}
+	if err := instances[0].Err; err != nil {
+		return cue.Value{}, err
 	}
+	return ctx.BuildInstance(instances[0]), nil
+}
 
-	return nil
+// reportErrors renders the CUE errors with an excerpt of the source at their positions to w, and returns them as diagnostics.
+func reportErrors(w io.Writer, err error) []diagnostic {
+	diagnostics := toDiagnostics(err)
+	renderDiagnostics(w, diagnostics)
+	return diagnostics
 }
 
-func validateCueSchemas() error {
-	logrus.Debugf("Starting CUE files validation")
+// packageResult is the outcome of the validation of a package.
+type packageResult struct {
+	schemaDir    string
+	output       bytes.Buffer
+	diagnostics  []diagnostic
+	err          error
+	uncovered    []string
+	definitions  int
+	undocumented []string
+	duration     time.Duration
+}
 
-	// Check if cue command is available
-	if _, err := exec.LookPath("cue"); err != nil {
-		return fmt.Errorf("cue command not found in PATH: %w", err)
+// checkPackage validates the package and runs the optional checks on it.
+func checkPackage(registry modconfig.Registry, schemaDir, testDir string, concrete bool, coverage bool, requireDocs bool) *packageResult {
+	result := &packageResult{schemaDir: schemaDir}
+	diagnostics, err := vetPackage(&result.output, registry, schemaDir, testDir, concrete)
+	result.diagnostics = diagnostics
+	if err != nil {
+		result.err = err
+		return result
 	}
+	if coverage {
+		if result.uncovered, result.definitions, err = uncoveredDefinitions(schemaDir, testDir); err != nil {
+			result.err = fmt.Errorf("failed to compute the coverage of %s: %w", schemaDir, err)
+			return result
+		}
+	}
+	if requireDocs {
+		if result.undocumented, err = undocumentedDefinitions(schemaDir); err != nil {
+			result.err = fmt.Errorf("failed to check the documentation of %s: %w", schemaDir, err)
+		}
+	}
+	return result
+}
+
+// checkPackageWithTimeout runs checkPackage, giving up when it takes longer than timeout or when ctx is done.
+// The CUE evaluation cannot be interrupted: a package giving up keeps being evaluated in the background until the run exits.
+func checkPackageWithTimeout(ctx context.Context, timeout time.Duration, registry modconfig.Registry, schemaDir, testDir string, concrete bool, coverage bool, requireDocs bool) *packageResult {
+	start := time.Now()
+	done := make(chan *packageResult, 1)
+	go func() {
+		done <- checkPackage(registry, schemaDir, testDir, concrete, coverage, requireDocs)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var result *packageResult
+	select {
+	case result = <-done:
+	case <-timer.C:
+		result = &packageResult{schemaDir: schemaDir, err: fmt.Errorf("timed out after %s", timeout)}
+	case <-ctx.Done():
+		result = &packageResult{schemaDir: schemaDir, err: fmt.Errorf("not completed before the deadline of the run: %w", ctx.Err())}
+	}
+	result.duration = time.Since(start)
+	return result
+}
+
+// validateOptions configures the validation of the schema packages by validateCueSchemas.
+type validateOptions struct {
+	// timeout is the maximum time given to the validation of each package
+	timeout     time.Duration
+	dirsInScope []string
+	excluded    []string
+	// filter restricts the validation to a package and its subpackages
+	filter string
+	// selected restricts the validation to the given packages, nil meaning every package
+	selected map[string]bool
+	// concrete lists the packages whose test files must be fully concrete
+	concrete    []string
+	coverage    bool
+	minCoverage float64
+	requireDocs bool
+	// format is the format of the diagnostics printed on stdout: text, sarif or github
+	format string
+	// output is the file to write the status of each package to, if any
+	output      string
+	parallelism int
+}
+
+func validateCueSchemas(ctx context.Context, registry modconfig.Registry, opts validateOptions) error {
+	logrus.Debugf("Starting CUE files validation")
 
-	validatedCount := 0
 	skippedCount := 0
 	errCount := 0
+	uncoveredCount := 0
+	definitionsCount := 0
+	undocumentedCount := 0
+	var diagnostics []diagnostic
+	var schemaDirs []string
+	var statuses []packageStatus
 
-	for _, dirInScope := range dirsInScope {
+	for _, dirInScope := range opts.dirsInScope {
 		logrus.Debugf("Processing directory: %s", dirInScope)
 		packageDirs, err := findPackages(schemasDir, dirInScope)
 		if err != nil {
@@ -128,35 +386,341 @@ func validateCueSchemas() error {
 		}
 
 		for _, packageDir := range packageDirs {
+			if opts.filter != "" && !isUnder(packageDir, []string{opts.filter}) {
+				continue
+			}
+			// a nil selection means every package is selected
+			if opts.selected != nil && !opts.selected[packageDir] {
+				continue
+			}
+			if isUnder(packageDir, opts.excluded) {
+				logrus.Infof("Skipping %s: excluded", filepath.Join(schemasDir, packageDir))
+				statuses = append(statuses, packageStatus{Package: filepath.Join(schemasDir, packageDir), Status: statusSkipped, Message: "excluded"})
+				skippedCount++
+				continue
+			}
 			schemaDir := filepath.Join(schemasDir, packageDir)
 			testDir := filepath.Join(testDir, packageDir)
 
+			if err := verifyModuleReachable(schemaDir); err != nil {
+				logrus.Errorf("Module check failed for %s: %v", schemaDir, err)
+				statuses = append(statuses, packageStatus{Package: schemaDir, Status: statusFailed, Message: err.Error()})
+				errCount++
+				continue
+			}
+
 			// Check if corresponding test directory exists
 			if _, err := os.Stat(testDir); os.IsNotExist(err) {
-				logrus.Debugf("Skipping %s: test directory %s not found", schemaDir, testDir)
-				skippedCount++
+				// in strict mode, an untested package fails the run, unless it's explicitly excluded
+				if warnErr := config.Warnf("Skipping %s: test directory %s not found", schemaDir, testDir); warnErr != nil {
+					logrus.Error(warnErr)
+					statuses = append(statuses, packageStatus{Package: schemaDir, Status: statusFailed, Message: "test directory not found"})
+					errCount++
+				} else {
+					statuses = append(statuses, packageStatus{Package: schemaDir, Status: statusSkipped, Message: "test directory not found"})
+					skippedCount++
+				}
+				if opts.coverage {
+					// none of the definitions of an untested package are covered
+					defs, err := definitions(schemaDir)
+					if err != nil {
+						return fmt.Errorf("failed to compute the coverage of %s: %w", schemaDir, err)
+					}
+					if len(defs) > 0 {
+						logrus.Warnf("Package %s has no test directory, its %d definition(s) are not covered", schemaDir, len(defs))
+					}
+					uncoveredCount += len(defs)
+					definitionsCount += len(defs)
+				}
 				continue
 			}
+			schemaDirs = append(schemaDirs, packageDir)
+		}
+	}
 
-			logrus.Infof("Validating package %s with test package %s", schemaDir, testDir)
-			if err := vetPackage(schemaDir, testDir); err != nil {
-				logrus.Errorf("Validation failed for %s: %v", schemaDir, err)
-				errCount++
-			}
+	if opts.filter != "" && len(statuses) == 0 && len(schemaDirs) == 0 {
+		return fmt.Errorf("no package %s found under %s", opts.filter, schemasDir)
+	}
+
+	// Validate the packages concurrently, the results are then reported in the order of the packages
+	start := time.Now()
+	results := parallel.Map(opts.parallelism, schemaDirs, func(packageDir string) *packageResult {
+		return checkPackageWithTimeout(ctx, opts.timeout, registry, filepath.Join(schemasDir, packageDir), filepath.Join(testDir, packageDir), isUnder(packageDir, opts.concrete), opts.coverage, opts.requireDocs)
+	})
+	for i, result := range results {
+		details := strings.TrimSpace(result.output.String())
+		if _, err := io.Copy(os.Stderr, &result.output); err != nil {
+			return err
+		}
+		diagnostics = append(diagnostics, result.diagnostics...)
+		if result.err != nil {
+			logrus.Errorf("Validation failed for %s: %v", result.schemaDir, result.err)
+			statuses = append(statuses, packageStatus{Package: result.schemaDir, Status: statusFailed, Message: strings.TrimSpace(result.err.Error() + "\n" + details), Duration: result.duration.Seconds()})
+			errCount++
+			continue
+		}
+		statuses = append(statuses, packageStatus{Package: result.schemaDir, Status: statusValidated, Duration: result.duration.Seconds()})
+		logrus.Infof("✓ Package %s validated with test package %s", result.schemaDir, filepath.Join(testDir, schemaDirs[i]))
+		for _, definition := range result.uncovered {
+			logrus.Warnf("Definition %s of package %s is not used by any test", definition, result.schemaDir)
+		}
+		uncoveredCount += len(result.uncovered)
+		definitionsCount += result.definitions
+		for _, definition := range result.undocumented {
+			logrus.Errorf("%s has no doc comment", definition)
+		}
+		undocumentedCount += len(result.undocumented)
+	}
+	logSummary(results, time.Since(start))
 
-			validatedCount++
+	if opts.output != "" {
+		if err := writeReport(opts.output, statuses); err != nil {
+			return fmt.Errorf("failed to write the report: %w", err)
+		}
+	}
+	switch opts.format {
+	case "sarif":
+		if err := writeSarif(os.Stdout, diagnostics); err != nil {
+			return fmt.Errorf("failed to write the SARIF report: %w", err)
+		}
+	case "github":
+		if err := writeAnnotations(os.Stdout, diagnostics); err != nil {
+			return fmt.Errorf("failed to write the GitHub annotations: %w", err)
 		}
 	}
 	if errCount > 0 {
 		return fmt.Errorf("validation failed for %d file(s)", errCount)
 	}
+	if undocumentedCount > 0 {
+		return fmt.Errorf("%d exported definition(s) without doc comment", undocumentedCount)
+	}
 
-	logrus.Infof("CUE files validation completed: %d validated, %d skipped", validatedCount, skippedCount)
+	logrus.Infof("CUE files validation completed: %d validated, %d skipped", len(results), skippedCount)
+	if opts.coverage {
+		covered := 100.0
+		if definitionsCount > 0 {
+			covered = 100 * float64(definitionsCount-uncoveredCount) / float64(definitionsCount)
+		}
+		logrus.Infof("CUE coverage completed: %d of %d definition(s) not covered by tests, %.1f%% covered", uncoveredCount, definitionsCount, covered)
+		if covered < opts.minCoverage {
+			return fmt.Errorf("CUE coverage %.1f%% is below the minimum of %.1f%%", covered, opts.minCoverage)
+		}
+	}
 	return nil
 }
 
+// splitDirs splits a comma-separated list of directories.
+func splitDirs(list string) []string {
+	var dirs []string
+	for _, dir := range strings.Split(list, ",") {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	return dirs
+}
+
 func main() {
-	if err := validateCueSchemas(); err != nil {
+	coverage := flag.Bool("coverage", false, "Report the schema definitions that are not used by any test")
+	minCoverage := flag.Float64("min-coverage", 0, "Fail when the percentage of schema definitions used by the tests is below this threshold. Implies -coverage")
+	requireDocs := flag.Bool("require-docs", false, "Fail when an exported schema definition is not preceded by a doc comment")
+	format := flag.String("format", "text", "Output format: text, sarif to print a SARIF report of the validation errors on stdout, or github to print them as GitHub Actions annotations")
+	dirs := flag.String("dirs", "", "Comma-separated list of the subdirectories of cue/ to validate, defaults to all of them")
+	packageFilter := flag.String("package", "", "Only validate the given package of cue/ (e.g. common/proxy) and its subpackages")
+	exclude := flag.String("exclude", strings.Join(excludedDirs, ","), "Comma-separated list of the subdirectories of cue/ not to validate")
+	compat := flag.Bool("compat", false, "Check that the schemas still accept every value accepted by their previous release, then exit")
+	compatRef := flag.String("compat-ref", "", "Reference to check the backward compatibility against, defaults to the previous v* tag")
+	publishedCheck := flag.Bool("published-check", false, "Check that the CUE module published to the registry for -published-ref matches cue/ at this tag, then exit")
+	publishedRef := flag.String("published-ref", "", "Tag of the published module to check, defaults to the previous v* tag")
+	vendorCheck := flag.Bool("vendor-check", false, "Check that the vendored schemas listed in -vendor-manifest are identical to their pinned upstream source, then exit")
+	vendorManifest := flag.String("vendor-manifest", "cue-vendor.json", "JSON manifest listing the vendored schemas, as {\"path\", \"url\"} entries")
+	jsonSchemaDir := flag.String("jsonschema", "", "Directory to write the JSON Schema of each schema definition to (e.g. dist/jsonschema), then exit")
+	openAPIDir := flag.String("openapi", "", "Directory to write the OpenAPI document of each schema package to (e.g. dist/openapi), then exit")
+	scaffold := flag.Bool("scaffold", false, "Create a test directory with a stub test file for each schema package that has none, then exit")
+	tidyCheck := flag.Bool("tidy-check", false, "Fail when cue.mod/module.cue doesn't declare exactly the imported modules, or when a dependency cannot be resolved")
+	concrete := flag.String("concrete", "", "Comma-separated list of the packages of cue/ whose test files must be fully concrete, like with `cue vet -c`")
+	changedOnly := flag.Bool("changed-only", false, "Only validate the packages whose schemas or tests changed since -base, and the packages importing them")
+	base := flag.String("base", "origin/main", "Reference the changes are computed against with -changed-only")
+	lint := flag.Bool("lint", false, "Fail when the schemas don't follow the conventions: doc comments, @deprecated attributes and kind naming")
+	fmtCheck := flag.Bool("fmt-check", false, "Fail when a .cue file of cue/ or cue-test/ is not formatted with `cue fmt`, printing the diff to apply")
+	flag.BoolVar(&updateGolden, "update", false, "Write the golden file of each test file with the result of its export, instead of comparing them")
+	flag.IntVar(&slowestCount, "slowest", 5, "Number of the slowest packages to list in the summary of the validation")
+	output := flag.String("output", "", "File to write the status of each package to, as JUnit XML if it has the .xml extension, as JSON otherwise")
+	timeout := flag.Duration("timeout", 5*time.Minute, "Maximum time given to the validation of each package")
+	deadline := flag.Duration("deadline", 30*time.Minute, "Maximum time given to the validation of all the packages")
+	parallelism := config.ParallelFlag()
+	config.RegisterFlags()
+	tagFlag := tag.Flag()
+	flag.Parse()
+
+	dirsInScope := splitDirs(*dirs)
+	if len(dirsInScope) == 0 {
+		discovered, err := discoverDirs(schemasDir)
+		if err != nil {
+			logrus.WithError(err).Fatalf("failed to list the directories of %s", schemasDir)
+		}
+		dirsInScope = discovered
+	}
+
+	if *format != "text" && *format != "sarif" && *format != "github" {
+		logrus.Fatalf("invalid format %q, expected text, sarif or github", *format)
+	}
+
+	// When validating a release, the CUE module must be published with a matching major version
+	if *tagFlag != "" {
+		if err := verifyModuleVersion(tag.Parse(tagFlag)); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
+	// the registry resolves the dependencies declared in cue.mod/module.cue, with the same configuration as the cue command
+	registry, err := modconfig.NewRegistry(nil)
+	if err != nil {
+		logrus.WithError(err).Fatal("failed to configure the CUE registry")
+	}
+
+	packageDir := *packageFilter
+	if packageDir != "" {
+		packageDir = filepath.Clean(packageDir)
+	}
+
+	if *compat {
+		ref := *compatRef
+		if ref == "" {
+			previousTag, err := git.PreviousTag("HEAD")
+			if err != nil {
+				logrus.WithError(err).Fatal("unable to get the previous tag")
+			}
+			if previousTag == "" {
+				logrus.Info("No previous tag found, nothing to check")
+				return
+			}
+			ref = previousTag
+		}
+		if err := checkCompatibility(registry, ref, dirsInScope, splitDirs(*exclude)); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	if *publishedCheck {
+		ref := *publishedRef
+		if ref == "" {
+			previousTag, err := git.PreviousTag("HEAD")
+			if err != nil {
+				logrus.WithError(err).Fatal("unable to get the previous tag")
+			}
+			if previousTag == "" {
+				logrus.Info("No previous tag found, nothing to check")
+				return
+			}
+			ref = previousTag
+		}
+		if err := verifyPublishedModule(registry, ref); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	if *vendorCheck {
+		if err := verifyVendoredFiles(*vendorManifest); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	if *jsonSchemaDir != "" {
+		if err := exportJSONSchemas(registry, *jsonSchemaDir, dirsInScope, splitDirs(*exclude)); err != nil {
+			logrus.WithError(err).Fatal("failed to export the JSON Schemas")
+		}
+		return
+	}
+
+	if *openAPIDir != "" {
+		if err := exportOpenAPI(registry, *openAPIDir, dirsInScope, splitDirs(*exclude)); err != nil {
+			logrus.WithError(err).Fatal("failed to export the OpenAPI documents")
+		}
+		return
+	}
+
+	if *scaffold {
+		if err := scaffoldTests(dirsInScope, splitDirs(*exclude)); err != nil {
+			logrus.WithError(err).Fatal("failed to scaffold the tests")
+		}
+		return
+	}
+
+	if *tidyCheck {
+		if err := verifyModuleTidy(registry); err != nil {
+			logrus.Fatal(err)
+		}
+	}
+
+	// the imports are checked on the whole tree, whatever the packages selected for the validation
+	if err := verifyImports(registry); err != nil {
+		logrus.Fatal(err)
+	}
+
+	if *lint {
+		var violations []string
+		err := forEachPackage(dirsInScope, splitDirs(*exclude), func(packageDir string) error {
+			packageViolations, err := lintPackage(filepath.Join(schemasDir, packageDir))
+			violations = append(violations, packageViolations...)
+			return err
+		})
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to lint the schemas")
+		}
+		if len(violations) > 0 {
+			logrus.Fatalf("%d convention violation(s) in the schemas:\n  %s", len(violations), strings.Join(violations, "\n  "))
+		}
+		logrus.Info("✓ Schemas follow the conventions")
+	}
+
+	if *fmtCheck {
+		unformatted, err := unformattedFiles(schemasDir, testDir)
+		if err != nil {
+			logrus.WithError(err).Fatal("failed to check the formatting of the CUE files")
+		}
+		if len(unformatted) > 0 {
+			logrus.Fatalf("%d CUE file(s) not formatted, run `cue fmt` on them:\n  %s", len(unformatted), strings.Join(unformatted, "\n  "))
+		}
+		logrus.Info("✓ CUE files formatted")
+	}
+
+	var selected map[string]bool
+	if *changedOnly {
+		changed, err := changedPackages(*base)
+		if err != nil {
+			logrus.WithError(err).Fatalf("unable to compute the packages changed since %s", *base)
+		}
+		if changed != nil && len(changed) == 0 {
+			logrus.Infof("No CUE package changed since %s, nothing to validate", *base)
+			return
+		}
+		if changed != nil {
+			logrus.Infof("Validating the packages impacted by the changes since %s: %s", *base, formatPackages(changed))
+		}
+		selected = changed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *deadline)
+	defer cancel()
+	err = validateCueSchemas(ctx, registry, validateOptions{
+		timeout:     *timeout,
+		dirsInScope: dirsInScope,
+		excluded:    splitDirs(*exclude),
+		filter:      packageDir,
+		selected:    selected,
+		concrete:    splitDirs(*concrete),
+		coverage:    *coverage || *minCoverage > 0,
+		minCoverage: *minCoverage,
+		requireDocs: *requireDocs,
+		format:      *format,
+		output:      *output,
+		parallelism: *parallelism,
+	})
+	if err != nil {
 		logrus.Fatal(err)
 	}
 }
@@ -0,0 +1,119 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestVetPackageFiles(t *testing.T) {
+	const schema = "package common\n\n#URL: =~\"^https?://\"\n"
+	tests := []struct {
+		name            string
+		testFiles       map[string]string
+		concrete        bool
+		wantErr         string
+		wantDiagnostics int
+	}{
+		{
+			name:      "valid test file",
+			testFiles: map[string]string{"url.cue": "package common\n\nurl: #URL & \"http://localhost:9090\"\n"},
+		},
+		{
+			name:            "test file rejected by the schemas",
+			testFiles:       map[string]string{"url.cue": "package common\n\nurl: #URL & \"localhost:9090\"\n"},
+			wantErr:         "failed to validate",
+			wantDiagnostics: 1,
+		},
+		{
+			name:      "invalid test file rejected",
+			testFiles: map[string]string{"url_invalid.cue": "package common\n\nurl: #URL & \"localhost:9090\"\n"},
+		},
+		{
+			name:            "invalid test file accepted",
+			testFiles:       map[string]string{"url_invalid.cue": "package common\n\nurl: #URL & \"http://localhost:9090\"\n"},
+			wantErr:         "1 invalid test file(s) accepted",
+			wantDiagnostics: 1,
+		},
+		{
+			name: "invalid test files vetted one by one",
+			testFiles: map[string]string{
+				"scheme_invalid.cue": "package common\n\nurl: #URL & \"localhost:9090\"\n",
+				"type_invalid.cue":   "package common\n\nurl: #URL & 9090\n",
+			},
+		},
+		{
+			name: "cases passing",
+			testFiles: map[string]string{"url_cases.cue": "package common\n\ncases: {\n" +
+				"\t\"http URL\": {schema: #URL, input: \"http://localhost:9090\", valid: true}\n" +
+				"\t\"missing scheme\": {schema: #URL, input: \"localhost:9090\", valid: false}\n}\n"},
+		},
+		{
+			name: "case failing",
+			testFiles: map[string]string{"url_cases.cue": "package common\n\ncases: {\n" +
+				"\t\"http URL\": {schema: #URL, input: \"http://localhost:9090\", valid: true}\n" +
+				"\t\"missing scheme\": {schema: #URL, input: \"localhost:9090\", valid: true}\n}\n"},
+			wantErr:         "1 of 2 case(s) of url_cases.cue failed",
+			wantDiagnostics: 1,
+		},
+		{
+			name:      "cases file without cases",
+			testFiles: map[string]string{"url_cases.cue": "package common\n\nurl: #URL\n"},
+			wantErr:   "has no cases field",
+		},
+		{
+			name:      "incomplete test file",
+			testFiles: map[string]string{"url.cue": "package common\n\nurl: #URL\n"},
+		},
+		{
+			name:      "incomplete test file in a concrete package",
+			testFiles: map[string]string{"url.cue": "package common\n\nurl: #URL\n"},
+			concrete:  true,
+			wantErr:   "failed to validate",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			files := map[string]string{
+				"cue/cue.mod/module.cue": testModule,
+				"cue/common/url.cue":     schema,
+			}
+			var testFiles []string
+			for name, content := range test.testFiles {
+				files[filepath.Join("cue-test/common", name)] = content
+				testFiles = append(testFiles, filepath.Join("cue-test/common", name))
+			}
+			testutil.WriteFiles(t, ".", files)
+
+			diagnostics, err := vetPackageFiles(io.Discard, cuecontext.New(), nil, "cue/common", []string{"cue/common/url.cue"}, testFiles, test.concrete)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("got error %v, expected %q", err, test.wantErr)
+			}
+			if test.wantDiagnostics > 0 && len(diagnostics) != test.wantDiagnostics {
+				t.Errorf("got %d diagnostic(s), expected %d: %v", len(diagnostics), test.wantDiagnostics, diagnostics)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const downloadTimeout = 30 * time.Second
+
+// vendoredFile is an entry of the vendor manifest: a file of cue/ copied from an upstream schema.
+type vendoredFile struct {
+	// Path is the path of the vendored copy, from the repository root (e.g. cue/common/foo/foo.cue).
+	Path string `json:"path"`
+	// URL is the location of the upstream source, pinned to a ref (e.g. a raw URL of a tagged file).
+	URL string `json:"url"`
+}
+
+// verifyVendoredFiles downloads the upstream source of each file of the manifest and checks that the vendored copy is identical,
+// so that an upstream change is only taken by updating the pinned ref of the manifest and the copy together.
+func verifyVendoredFiles(manifest string) error {
+	data, err := os.ReadFile(manifest) //nolint: gosec
+	if errors.Is(err, os.ErrNotExist) {
+		logrus.Infof("No vendor manifest %s found, nothing to check", manifest)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", manifest, err)
+	}
+	var files []vendoredFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", manifest, err)
+	}
+
+	client := &http.Client{Timeout: downloadTimeout}
+	var issues []string
+	for _, f := range files {
+		if f.Path == "" || f.URL == "" {
+			return fmt.Errorf("each entry of %s must have a path and a url", manifest)
+		}
+		local, err := os.ReadFile(f.Path)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: %v", f.Path, err))
+			continue
+		}
+		upstream, err := download(client, f.URL)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("%s: unable to download %s: %v", f.Path, f.URL, err))
+			continue
+		}
+		if !bytes.Equal(local, upstream) {
+			issues = append(issues, fmt.Sprintf("%s has drifted from %s", f.Path, f.URL))
+			continue
+		}
+		logrus.Infof("✓ %s matches %s", f.Path, f.URL)
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("%d vendored file(s) of %s not matching their upstream source:\n  %s", len(issues), manifest, strings.Join(issues, "\n  "))
+	}
+	return nil
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url) //nolint: noctx
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server responded with status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,98 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil holds the helpers shared by the tests of the scripts: fixture files, throwaway git repositories,
+// and fake commands standing in for the external tools (npm, gh, git) the scripts shell out to.
+package testutil
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// WriteFiles creates the given files, relative to root, with their parent directories.
+func WriteFiles(t testing.TB, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// FakeCommand installs a shell script named name in front of the PATH for the duration of the test.
+// The script receives the arguments of the command, like the tool it stands for.
+func FakeCommand(t *testing.T, name string, script string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0700); err != nil { //nolint: gosec
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// GitRepo creates an empty git repository in a temporary directory and makes it the current directory for the duration
+// of the test. The user configuration is ignored, so that e.g. commit signing doesn't get in the way.
+func GitRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "Perses")
+	t.Setenv("GIT_AUTHOR_EMAIL", "perses@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Perses")
+	t.Setenv("GIT_COMMITTER_EMAIL", "perses@example.com")
+	Git(t, "init", "--quiet", "--initial-branch", "main")
+	return dir
+}
+
+// Git runs git with the given arguments in the current directory and returns its trimmed output, failing the test on error.
+func Git(t *testing.T, args ...string) string {
+	t.Helper()
+	data, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, data)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Commit creates a commit with the given subject, touching the given files (or a file named after the subject if none).
+func Commit(t *testing.T, subject string, files ...string) {
+	t.Helper()
+	if len(files) == 0 {
+		files = []string{strings.NewReplacer(" ", "-", "/", "-", ":", "").Replace(subject) + ".txt"}
+	}
+	for _, f := range files {
+		WriteFiles(t, ".", map[string]string{f: subject + "\n"})
+		Git(t, "add", f)
+	}
+	Git(t, "commit", "--quiet", "--message", subject)
+}
+
+// ReadFile returns the content of the given file, failing the test if it can't be read.
+func ReadFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name) //nolint: gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,91 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type step struct {
+	name     string
+	duration time.Duration
+}
+
+// Report collects the duration of the steps of a script run.
+type Report struct {
+	mutex sync.Mutex
+	start time.Time
+	steps []step
+}
+
+// New creates a report, the total duration being measured from now.
+func New() *Report {
+	return &Report{start: time.Now()}
+}
+
+// Track starts timing the step name. The returned function must be called when the step is complete.
+func (r *Report) Track(name string) func() {
+	start := time.Now()
+	return func() {
+		r.Add(name, time.Since(start))
+	}
+}
+
+// Add records the duration of the step name. Durations of steps with the same name are summed.
+func (r *Report) Add(name string, duration time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.steps {
+		if r.steps[i].name == name {
+			r.steps[i].duration += duration
+			return
+		}
+	}
+	r.steps = append(r.steps, step{name: name, duration: duration})
+}
+
+// String renders the total wall time followed by every step, the longest first.
+func (r *Report) String() string {
+	r.mutex.Lock()
+	steps := make([]step, len(r.steps))
+	copy(steps, r.steps)
+	r.mutex.Unlock()
+
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].duration > steps[j].duration
+	})
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "Total duration: %s", time.Since(r.start).Round(time.Millisecond))
+	for _, s := range steps {
+		fmt.Fprintf(&builder, "\n  %-50s %s", s.name, s.duration.Round(time.Millisecond))
+	}
+	return builder.String()
+}
+
+// Log prints the report.
+func (r *Report) Log() {
+	logrus.Info(r.String())
+}
+
+// LogOnExit makes sure the report is printed when the program exits through logrus.Fatal, so that failed runs report where
+// the time went too. The caller is responsible for calling Log when returning normally.
+func (r *Report) LogOnExit() {
+	logrus.RegisterExitHandler(r.Log)
+}
@@ -0,0 +1,77 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timing
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportString(t *testing.T) {
+	type added struct {
+		name     string
+		duration time.Duration
+	}
+	tests := []struct {
+		name  string
+		added []added
+		want  []string
+	}{
+		{
+			name: "no step",
+		},
+		{
+			name: "steps sorted by duration",
+			added: []added{
+				{name: "pack", duration: 2 * time.Second},
+				{name: "verify versions", duration: 150 * time.Millisecond},
+				{name: "publish", duration: 5 * time.Second},
+			},
+			want: []string{
+				fmt.Sprintf("  %-50s %s", "publish", "5s"),
+				fmt.Sprintf("  %-50s %s", "pack", "2s"),
+				fmt.Sprintf("  %-50s %s", "verify versions", "150ms"),
+			},
+		},
+		{
+			name: "durations of a step aggregated",
+			added: []added{
+				{name: "publish @perses-dev/core", duration: 1200 * time.Millisecond},
+				{name: "wait for availability", duration: 300 * time.Millisecond},
+				{name: "publish @perses-dev/core", duration: 800 * time.Millisecond},
+			},
+			want: []string{
+				fmt.Sprintf("  %-50s %s", "publish @perses-dev/core", "2s"),
+				fmt.Sprintf("  %-50s %s", "wait for availability", "300ms"),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := New()
+			for _, a := range test.added {
+				report.Add(a.name, a.duration)
+			}
+			lines := strings.Split(report.String(), "\n")
+			if !strings.HasPrefix(lines[0], "Total duration: ") {
+				t.Errorf("the report starts with %q, expected the total duration", lines[0])
+			}
+			if got := lines[1:]; strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got steps:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
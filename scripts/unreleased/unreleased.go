@@ -0,0 +1,75 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/perses/shared/scripts/git"
+	"github.com/perses/shared/scripts/notes"
+	"github.com/sirupsen/logrus"
+)
+
+// unreleased returns the commits reachable from to since the last tag, or all of them when there is no tag yet.
+func unreleased(to string) ([]string, error) {
+	lastTag, err := git.PreviousTag(to)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the last tag: %w", err)
+	}
+	if lastTag == "" {
+		logrus.Info("no tag found, listing all the commits")
+	} else {
+		logrus.Infof("listing the changes since %s", lastTag)
+	}
+	return git.Log(lastTag, to)
+}
+
+// This script prints the changes that would go into the next release, i.e. the changelog since the last tag.
+// When the repository has no tag yet, every commit is considered unreleased.
+//
+// Usage:
+//
+//	go run ./scripts/unreleased
+//
+// As JSON:
+//
+//	go run ./scripts/unreleased --format json
+func main() {
+	format := flag.String("format", "md", "Output format: md or json")
+	flag.Parse()
+
+	if *format != "md" && *format != "json" {
+		logrus.Fatalf("invalid format %q, expected md or json", *format)
+	}
+
+	entries, err := unreleased("HEAD")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if len(entries) == 0 {
+		logrus.Info("no unreleased change")
+		return
+	}
+
+	if *format == "json" {
+		data, err := notes.JSON(entries, notes.TypeFilter{})
+		if err != nil {
+			logrus.WithError(err).Fatal("unable to generate the changelog")
+		}
+		fmt.Println(string(data))
+		return
+	}
+	fmt.Print(notes.Markdown(entries, notes.TypeFilter{}))
+}
@@ -0,0 +1,74 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/perses/shared/scripts/notes"
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestUnreleased(t *testing.T) {
+	tests := []struct {
+		name     string
+		tag      bool
+		wantLogs []string
+	}{
+		{
+			name:     "changes since the last tag",
+			tag:      true,
+			wantLogs: []string{"[BUGFIX] fix the panel header", "[FEATURE] add a markdown panel"},
+		},
+		{
+			name:     "no tag yet",
+			wantLogs: []string{"[BUGFIX] fix the panel header", "[FEATURE] add a markdown panel", "[FEATURE] add the core library"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.GitRepo(t)
+			// the previous tag is cached by reference for the whole test binary: a file named after the case makes sure the
+			// commits differ from a case to the other
+			testutil.Commit(t, "[FEATURE] add the core library", strings.ReplaceAll(test.name, " ", "-")+".txt")
+			if test.tag {
+				testutil.Git(t, "tag", "--annotate", "--message", "v0.1.0", "v0.1.0")
+			}
+			testutil.Commit(t, "[FEATURE] add a markdown panel")
+			testutil.Commit(t, "[BUGFIX] fix the panel header")
+
+			entries, err := unreleased(testutil.Git(t, "rev-parse", "HEAD"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			subjects := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				_, subject, _ := strings.Cut(entry, " ")
+				subjects = append(subjects, subject)
+			}
+			if !slices.Equal(subjects, test.wantLogs) {
+				t.Fatalf("got unreleased commits %q, expected %q", subjects, test.wantLogs)
+			}
+			markdown := notes.Markdown(entries, notes.TypeFilter{})
+			if !strings.Contains(markdown, "add a markdown panel") || !strings.Contains(markdown, "fix the panel header") {
+				t.Errorf("unreleased changes missing from the notes:\n%s", markdown)
+			}
+			if test.tag && strings.Contains(markdown, "add the core library") {
+				t.Errorf("released change listed in the notes:\n%s", markdown)
+			}
+		})
+	}
+}
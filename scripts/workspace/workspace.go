@@ -0,0 +1,157 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Match is a workspace directory with the workspace patterns of the root package.json that match it.
+type Match struct {
+	Dir      string
+	Patterns []string
+}
+
+// Expand resolves the workspace patterns of the root package.json (e.g. `packages/*`) to the directories they match,
+// in the order of the patterns. Only the directories containing a package.json are considered as workspaces.
+func Expand(patterns []string) ([]Match, error) {
+	var matches []Match
+	for _, pattern := range patterns {
+		dirs, err := filepath.Glob(filepath.Clean(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+		for _, dir := range dirs {
+			if _, err := os.Stat(filepath.Join(dir, "package.json")); err != nil {
+				continue
+			}
+			i := slices.IndexFunc(matches, func(m Match) bool { return m.Dir == dir })
+			if i < 0 {
+				matches = append(matches, Match{Dir: dir})
+				i = len(matches) - 1
+			}
+			matches[i].Patterns = append(matches[i].Patterns, pattern)
+		}
+	}
+	return matches, nil
+}
+
+// Overlaps returns the workspace directories matched more than once by the given patterns.
+func Overlaps(patterns []string) ([]Match, error) {
+	matches, err := Expand(patterns)
+	if err != nil {
+		return nil, err
+	}
+	var overlaps []Match
+	for _, m := range matches {
+		if len(m.Patterns) > 1 {
+			overlaps = append(overlaps, m)
+		}
+	}
+	return overlaps, nil
+}
+
+// Workspace is the resolved model of a workspace of the monorepo.
+type Workspace struct {
+	Dir              string            `json:"dir"`
+	Name             string            `json:"name"`
+	Version          string            `json:"version"`
+	Private          bool              `json:"private"`
+	Dependencies     map[string]string `json:"dependencies,omitempty"`
+	PeerDependencies map[string]string `json:"peerDependencies,omitempty"`
+	DevDependencies  map[string]string `json:"devDependencies,omitempty"`
+	// InternalDependencies are the names of the other workspaces this one depends on at runtime (dependencies and
+	// peerDependencies): they're the edges of the build and publish order.
+	InternalDependencies []string `json:"internalDependencies"`
+	// InternalDevDependencies are the names of the other workspaces this one only needs for its development (e.g. its tests).
+	// They don't constrain the order, two workspaces can use each other in their tests.
+	InternalDevDependencies []string `json:"internalDevDependencies"`
+}
+
+// Load expands the workspace patterns and reads the package.json of each workspace.
+func Load(patterns []string) ([]Workspace, error) {
+	matches, err := Expand(patterns)
+	if err != nil {
+		return nil, err
+	}
+	workspaces := make([]Workspace, 0, len(matches))
+	for _, m := range matches {
+		data, err := os.ReadFile(filepath.Join(m.Dir, "package.json")) //nolint: gosec
+		if err != nil {
+			return nil, fmt.Errorf("unable to read package.json for workspace %s: %w", m.Dir, err)
+		}
+		w := Workspace{Dir: m.Dir}
+		if err := json.Unmarshal(data, &w); err != nil {
+			return nil, fmt.Errorf("unable to parse package.json for workspace %s: %w", m.Dir, err)
+		}
+		w.Dir = m.Dir
+		workspaces = append(workspaces, w)
+	}
+
+	names := make(map[string]bool, len(workspaces))
+	for _, w := range workspaces {
+		names[w.Name] = true
+	}
+	for i, w := range workspaces {
+		workspaces[i].InternalDependencies = internalNames(names, w.Name, w.Dependencies, w.PeerDependencies)
+		workspaces[i].InternalDevDependencies = internalNames(names, w.Name, w.DevDependencies)
+	}
+	return workspaces, nil
+}
+
+// internalNames returns the sorted names of the workspaces, other than self, listed in the given dependencies.
+func internalNames(names map[string]bool, self string, dependencies ...map[string]string) []string {
+	internal := make(map[string]bool)
+	for _, deps := range dependencies {
+		for name := range deps {
+			if names[name] && name != self {
+				internal[name] = true
+			}
+		}
+	}
+	return append([]string{}, slices.Sorted(maps.Keys(internal))...)
+}
+
+// TopologicalOrder returns the names of the workspaces ordered so that each workspace comes after its internal dependencies.
+// Workspaces without dependency between them keep the order of the root package.json.
+func TopologicalOrder(workspaces []Workspace) ([]string, error) {
+	var order []string
+	done := make(map[string]bool, len(workspaces))
+	for len(order) < len(workspaces) {
+		progress := false
+		for _, w := range workspaces {
+			if done[w.Name] || slices.ContainsFunc(w.InternalDependencies, func(name string) bool { return !done[name] }) {
+				continue
+			}
+			order = append(order, w.Name)
+			done[w.Name] = true
+			progress = true
+		}
+		if !progress {
+			var cycle []string
+			for _, w := range workspaces {
+				if !done[w.Name] {
+					cycle = append(cycle, w.Name)
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle between the workspaces %v", cycle)
+		}
+	}
+	return order, nil
+}
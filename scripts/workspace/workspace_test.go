@@ -0,0 +1,165 @@
+// Copyright The Perses Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workspace
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/perses/shared/scripts/testutil"
+)
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []Match
+	}{
+		{
+			name:     "disjoint patterns",
+			patterns: []string{"core", "plugins/*"},
+		},
+		{
+			name:     "glob overlapping a workspace",
+			patterns: []string{"core", "plugins/*", "plugins/timeseries"},
+			want:     []Match{{Dir: "plugins/timeseries", Patterns: []string{"plugins/*", "plugins/timeseries"}}},
+		},
+		{
+			name:     "overlapping globs",
+			patterns: []string{"*", "plugins/*", "plugins/time*"},
+			want:     []Match{{Dir: "plugins/timeseries", Patterns: []string{"plugins/*", "plugins/time*"}}},
+		},
+		{
+			name:     "workspace listed twice",
+			patterns: []string{"core", "./core"},
+			want:     []Match{{Dir: "core", Patterns: []string{"core", "./core"}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", map[string]string{
+				"core/package.json":               `{"name": "@perses-dev/core"}`,
+				"plugins/table/package.json":      `{"name": "@perses-dev/table"}`,
+				"plugins/timeseries/package.json": `{"name": "@perses-dev/timeseries"}`,
+				"plugins/README.md":               "# Plugins\n",
+			})
+			overlaps, err := Overlaps(test.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(overlaps, test.want) {
+				t.Errorf("got overlaps %+v, expected %+v", overlaps, test.want)
+			}
+		})
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		workspaces []Workspace
+		want       []string
+		wantErr    bool
+	}{
+		{
+			name: "independent workspaces keep their order",
+			workspaces: []Workspace{
+				{Name: "b"},
+				{Name: "a"},
+			},
+			want: []string{"b", "a"},
+		},
+		{
+			name: "dependencies first",
+			workspaces: []Workspace{
+				{Name: "timeseries", InternalDependencies: []string{"components", "core"}},
+				{Name: "components", InternalDependencies: []string{"core"}},
+				{Name: "core"},
+			},
+			want: []string{"core", "components", "timeseries"},
+		},
+		{
+			name: "cycle",
+			workspaces: []Workspace{
+				{Name: "core"},
+				{Name: "components", InternalDependencies: []string{"timeseries"}},
+				{Name: "timeseries", InternalDependencies: []string{"components"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := TopologicalOrder(test.workspaces)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(order, test.want) {
+				t.Errorf("got order %q, expected %q", order, test.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		wantOrder []string
+		wantErr   bool
+	}{
+		{
+			name: "runtime dependencies",
+			files: map[string]string{
+				"core/package.json":       `{"name": "@perses-dev/core"}`,
+				"components/package.json": `{"name": "@perses-dev/components", "peerDependencies": {"@perses-dev/core": "*"}}`,
+			},
+			wantOrder: []string{"@perses-dev/core", "@perses-dev/components"},
+		},
+		{
+			name: "dev-only cycle",
+			files: map[string]string{
+				"core/package.json":       `{"name": "@perses-dev/core", "devDependencies": {"@perses-dev/components": "*"}}`,
+				"components/package.json": `{"name": "@perses-dev/components", "dependencies": {"@perses-dev/core": "*"}}`,
+			},
+			wantOrder: []string{"@perses-dev/core", "@perses-dev/components"},
+		},
+		{
+			name: "runtime cycle",
+			files: map[string]string{
+				"core/package.json":       `{"name": "@perses-dev/core", "peerDependencies": {"@perses-dev/components": "*"}}`,
+				"components/package.json": `{"name": "@perses-dev/components", "dependencies": {"@perses-dev/core": "*"}}`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			testutil.WriteFiles(t, ".", test.files)
+			workspaces, err := Load([]string{"core", "components"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			order, err := TopologicalOrder(workspaces)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, expected an error: %t", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(order, test.wantOrder) {
+				t.Errorf("got order %q, expected %q", order, test.wantOrder)
+			}
+		})
+	}
+}